@@ -1,13 +1,63 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"os"
-	"strings"
+	"os/signal"
 	"tree-problem/tree"
 )
 
+// Exit codes of the command.
+const (
+	exitOK    = 0
+	exitError = 1 // the tree could not be written or read in full
+	exitUsage = 2 // the command line is invalid
+)
+
 func main() {
-	cmd := "tree " + strings.Join(os.Args[1:], " ")
-	fmt.Println(tree.ListDirAndFiles(tree.ParseCommand(cmd)))
+	os.Exit(run())
+}
+
+func run() int {
+	config, err := tree.ParseArgs(os.Args[1:])
+	if errors.Is(err, tree.ErrHelp) {
+		fmt.Print(tree.Usage)
+		return exitOK
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tree: %v\nTry 'tree --help' for more information.\n", err)
+		return exitUsage
+	}
+
+	config.Terminal = isTerminal(os.Stdout)
+	// an interrupt ends the walk early but still closes the listing
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	out := bufio.NewWriter(os.Stdout)
+	err = tree.WriteTreeContext(ctx, out, config)
+	if ferr := out.Flush(); ferr != nil {
+		err = ferr
+	}
+	if err == nil {
+		return exitOK
+	}
+	var walkErr *tree.WalkError
+	if !errors.As(err, &walkErr) {
+		fmt.Fprintf(os.Stderr, "tree: %v\n", err)
+		return exitError
+	}
+	// the tree was written, with the failures noted inline
+	for _, e := range walkErr.Errs {
+		fmt.Fprintf(os.Stderr, "tree: %v\n", e)
+	}
+	// a walk cut short can also have failed to read parts of the tree
+	for _, cause := range []error{context.Canceled, context.DeadlineExceeded} {
+		if errors.Is(err, cause) {
+			fmt.Fprintf(os.Stderr, "tree: listing truncated: %v\n", cause)
+		}
+	}
+	return exitError
 }
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !windows
+
+package main
+
+import "os"
+
+// isTerminal reports no terminal where the platform offers no way to tell.
+func isTerminal(f *os.File) bool {
+	return false
+}
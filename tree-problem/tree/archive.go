@@ -0,0 +1,193 @@
+package tree
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	pathpkg "path"
+	"sort"
+	"strings"
+	"time"
+)
+
+var archiveExts = []string{".zip", ".tar", ".tar.gz", ".tgz"}
+
+func isArchive(name string) bool {
+	name = strings.ToLower(name)
+	for _, ext := range archiveExts {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// openArchive returns the contents of the archive at name in fsys as an
+// fs.FS rooted at ".". Only the listing is kept, so members cannot be read.
+func openArchive(fsys fs.FS, name string) (fs.FS, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return readZip(f)
+	case strings.HasSuffix(lower, ".tar"):
+		return readTar(f)
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	return readTar(gz)
+}
+
+func readZip(f fs.File) (fs.FS, error) {
+	var ra io.ReaderAt
+	var size int64
+	if r, ok := f.(io.ReaderAt); ok {
+		fi, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		ra, size = r, fi.Size()
+	} else {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+		ra, size = bytes.NewReader(data), int64(len(data))
+	}
+
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return nil, err
+	}
+
+	afs := newArchiveFS()
+	for _, zf := range zr.File {
+		afs.add(zf.Name, zf.FileInfo())
+	}
+	return afs, nil
+}
+
+func readTar(r io.Reader) (fs.FS, error) {
+	afs := newArchiveFS()
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		afs.add(hdr.Name, hdr.FileInfo())
+	}
+	return afs, nil
+}
+
+// archiveFS is a listing-only fs.FS built from archive headers.
+type archiveFS map[string]*archiveEntry
+
+type archiveEntry struct {
+	info     fs.FileInfo
+	children map[string]bool
+}
+
+func newArchiveFS() archiveFS {
+	return archiveFS{".": &archiveEntry{info: archiveDirInfo(".")}}
+}
+
+// add records the member name, skipping names that would escape the archive.
+func (afs archiveFS) add(name string, info fs.FileInfo) {
+	name = pathpkg.Clean(strings.TrimPrefix(name, "/"))
+	if name == "." || !fs.ValidPath(name) {
+		return
+	}
+
+	if e, ok := afs[name]; ok {
+		e.info = info
+	} else {
+		afs[name] = &archiveEntry{info: info}
+	}
+
+	// archives may omit entries for intermediate directories
+	for name != "." {
+		dir := pathpkg.Dir(name)
+		parent, ok := afs[dir]
+		if !ok {
+			parent = &archiveEntry{info: archiveDirInfo(dir)}
+			afs[dir] = parent
+		}
+		if parent.children == nil {
+			parent.children = make(map[string]bool)
+		}
+		parent.children[name] = true
+		name = dir
+	}
+}
+
+func (afs archiveFS) Open(name string) (fs.File, error) {
+	e, ok := afs[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &archiveFile{info: e.info}, nil
+}
+
+func (afs archiveFS) Stat(name string) (fs.FileInfo, error) {
+	e, ok := afs[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return e.info, nil
+}
+
+func (afs archiveFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	e, ok := afs[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	if !e.info.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(e.children))
+	for child := range e.children {
+		entries = append(entries, fs.FileInfoToDirEntry(afs[child].info))
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+type archiveFile struct {
+	info fs.FileInfo
+}
+
+func (f *archiveFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *archiveFile) Close() error               { return nil }
+
+func (f *archiveFile) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: f.info.Name(), Err: fs.ErrInvalid}
+}
+
+// archiveDirInfo describes a directory that has no header of its own.
+type archiveDirInfo string
+
+func (d archiveDirInfo) Name() string       { return pathpkg.Base(string(d)) }
+func (d archiveDirInfo) Size() int64        { return 0 }
+func (d archiveDirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0755 }
+func (d archiveDirInfo) ModTime() time.Time { return time.Time{} }
+func (d archiveDirInfo) IsDir() bool        { return true }
+func (d archiveDirInfo) Sys() any           { return nil }
@@ -0,0 +1,94 @@
+package tree
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func zipData(t *testing.T, names ...string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		_, err := zw.Create(name)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func tarGzData(t *testing.T, names ...string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range names {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Typeflag: tar.TypeReg}))
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestListArchives(t *testing.T) {
+	fsys := fstest.MapFS{
+		"dist":             dir(0),
+		"dist/app.zip":     &fstest.MapFile{Data: zipData(t, "bin/app", "README.md"), Mode: 0644},
+		"dist/src.tar.gz":  &fstest.MapFile{Data: tarGzData(t, "src/main.go", "src/lib/lib.go"), Mode: 0644},
+		"dist/notes.txt":   file(1),
+		"dist/broken.zip":  &fstest.MapFile{Data: []byte("not a zip"), Mode: 0644},
+		"dist/nested":      dir(2),
+		"dist/nested/a.go": file(3),
+	}
+
+	tests := []test{
+		{cmd: "tree dist", desc: "archives are plain files by default",
+			want: "dist\n" +
+				"│── app.zip\n" +
+				"│── broken.zip\n" +
+				"│── nested\n" +
+				"│   └── a.go\n" +
+				"│── notes.txt\n" +
+				"└── src.tar.gz\n\n" +
+				"1 directory, 5 files"},
+		{cmd: "tree --archives -L 2 dist", desc: "archive members listed as a subtree",
+			want: "dist\n" +
+				"│── app.zip\n" +
+				"│   │── README.md\n" +
+				"│   └── bin\n" +
+				"│── broken.zip [error opening archive]\n" +
+				"│── nested\n" +
+				"│   └── a.go\n" +
+				"│── notes.txt\n" +
+				"└── src.tar.gz\n" +
+				"    └── src\n\n" +
+				"3 directories, 6 files"},
+		{cmd: "tree --archives -d dist", desc: "only directories keeps archives",
+			want: "dist\n" +
+				"│── app.zip\n" +
+				"│   └── bin\n" +
+				"│── broken.zip [error opening archive]\n" +
+				"│── nested\n" +
+				"└── src.tar.gz\n" +
+				"    └── src\n" +
+				"        └── lib\n\n" +
+				"4 directories"},
+		{cmd: "tree --archives -J dist/app.zip", desc: "archive root in JSON",
+			want: "[\n  {\"type\":\"directory\",\"name\":\"dist/app.zip\",\"contents\":[\n" +
+				"    {\"type\":\"file\",\"name\":\"README.md\",\"archive\":\"dist/app.zip\"},\n" +
+				"    {\"type\":\"directory\",\"name\":\"bin\",\"archive\":\"dist/app.zip\",\"contents\":[\n" +
+				"      {\"type\":\"file\",\"name\":\"app\",\"archive\":\"dist/app.zip\"}\n" +
+				"    ]}\n  ]}\n,\n" +
+				"  {\"type\":\"report\",\"directories\":1,\"files\":2}\n]"},
+	}
+
+	for _, tc := range tests {
+		config := mustParse(t, tc.cmd)
+		config.FS = fsys
+		assert.Equal(t, tc.want, ListDirAndFiles(config), tc.desc)
+	}
+}
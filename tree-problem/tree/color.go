@@ -0,0 +1,119 @@
+package tree
+
+import (
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// defaultLSColors is used when LS_COLORS is unset, following the defaults
+// of dircolors.
+const defaultLSColors = "di=01;34:ln=01;36:pi=40;33:so=01;35:do=01;35:bd=40;33;01:cd=40;33;01:or=40;31;01:ex=01;32:" +
+	"*.tar=01;31:*.tgz=01;31:*.gz=01;31:*.bz2=01;31:*.xz=01;31:*.zst=01;31:*.zip=01;31:*.7z=01;31:*.rar=01;31:" +
+	"*.jpg=01;35:*.jpeg=01;35:*.gif=01;35:*.png=01;35:*.svg=01;35:*.webp=01;35:*.mp4=01;35:*.mkv=01;35:" +
+	"*.mp3=00;36:*.flac=00;36:*.ogg=00;36:*.wav=00;36"
+
+type colorMode int
+
+const (
+	colorAuto colorMode = iota // colors when writing to a terminal
+	colorAlways
+	colorNever
+)
+
+// colorize reports whether names are colored. Only the text output is.
+func (c TreeConfig) colorize() bool {
+	if c.reqJsonFormat || c.reqXmlFormat || c.reqHtmlFormat {
+		return false
+	}
+	return c.color == colorAlways || (c.color == colorAuto && c.Terminal)
+}
+
+// lsColors holds the SGR sequences of LS_COLORS, by entry kind ("di",
+// "ln", ...) and by file name suffix ("*.tar").
+type lsColors struct {
+	kinds    map[string]string
+	suffixes map[string]string
+}
+
+func newLSColors() *lsColors {
+	s := os.Getenv("LS_COLORS")
+	if s == "" {
+		s = defaultLSColors
+	}
+	return parseLSColors(s)
+}
+
+func parseLSColors(s string) *lsColors {
+	c := &lsColors{kinds: map[string]string{}, suffixes: map[string]string{}}
+	for _, field := range strings.Split(s, ":") {
+		i := strings.IndexByte(field, '=')
+		if i < 1 {
+			continue
+		}
+		key, code := field[:i], field[i+1:]
+		if strings.HasPrefix(key, "*") {
+			c.suffixes[key[1:]] = code
+			continue
+		}
+		c.kinds[key] = code
+	}
+	return c
+}
+
+// paint wraps s, the name of n, in the color of n. A nil lsColors leaves s
+// as is.
+func (c *lsColors) paint(n *Node, s string) string {
+	if c == nil {
+		return s
+	}
+	code := c.code(n)
+	if code == "" || code == "0" || code == "00" {
+		return s
+	}
+	return "\x1b[" + code + "m" + s + "\x1b[0m"
+}
+
+func (c *lsColors) code(n *Node) string {
+	if n.Type == Link {
+		if code, ok := c.kinds["or"]; ok && n.Broken {
+			return code
+		}
+		if code := c.kinds["ln"]; code != "target" {
+			return code
+		}
+		if n.linkDir {
+			return c.kinds["di"]
+		}
+		return c.fileCode(n)
+	}
+	if n.Type == Directory {
+		return c.kinds["di"]
+	}
+
+	switch m := n.Mode; {
+	case m&fs.ModeNamedPipe != 0:
+		return c.kinds["pi"]
+	case m&fs.ModeSocket != 0:
+		return c.kinds["so"]
+	case m&fs.ModeCharDevice != 0:
+		return c.kinds["cd"]
+	case m&fs.ModeDevice != 0:
+		return c.kinds["bd"]
+	case m&0111 != 0 && c.kinds["ex"] != "":
+		return c.kinds["ex"]
+	}
+	return c.fileCode(n)
+}
+
+// fileCode returns the color of a regular file: that of its longest
+// matching suffix, or the fi color.
+func (c *lsColors) fileCode(n *Node) string {
+	code, longest := c.kinds["fi"], 0
+	for suffix, sc := range c.suffixes {
+		if len(suffix) > longest && strings.HasSuffix(n.Name, suffix) {
+			code, longest = sc, len(suffix)
+		}
+	}
+	return code
+}
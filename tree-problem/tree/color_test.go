@@ -0,0 +1,93 @@
+package tree
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColors(t *testing.T) {
+	t.Setenv("LS_COLORS", "di=01;34:ex=01;32:*.tar.gz=01;31:*.gz=31:*.txt=0")
+	fsys := fstest.MapFS{
+		"c":           dir(0),
+		"c/bin":       dir(1),
+		"c/bin/run":   &fstest.MapFile{Mode: 0755},
+		"c/a.tar.gz":  file(2),
+		"c/b.gz":      file(3),
+		"c/notes.txt": file(4),
+	}
+
+	tests := []struct {
+		cmd, desc, want string
+		terminal        bool
+	}{
+		{"tree -C c", "-C colors by type, mode and the longest suffix",
+			"\x1b[01;34mc\x1b[0m\n" +
+				"│── \x1b[01;31ma.tar.gz\x1b[0m\n" +
+				"│── \x1b[31mb.gz\x1b[0m\n" +
+				"│── \x1b[01;34mbin\x1b[0m\n" +
+				"│   └── \x1b[01;32mrun\x1b[0m\n" +
+				"└── notes.txt\n\n" +
+				"1 directory, 4 files", false},
+		{"tree -C -f -p -L 1 c", "only the name is colored",
+			"\x1b[01;34mc\x1b[0m\n" +
+				"│── [-rw-r--r--]  \x1b[01;31mc/a.tar.gz\x1b[0m\n" +
+				"│── [-rw-r--r--]  \x1b[31mc/b.gz\x1b[0m\n" +
+				"│── [drwxr-xr-x]  \x1b[01;34mc/bin\x1b[0m\n" +
+				"└── [-rw-r--r--]  c/notes.txt\n\n" +
+				"1 directory, 3 files", false},
+		{"tree -L 1 c", "a terminal turns colors on",
+			"\x1b[01;34mc\x1b[0m\n" +
+				"│── \x1b[01;31ma.tar.gz\x1b[0m\n" +
+				"│── \x1b[31mb.gz\x1b[0m\n" +
+				"│── \x1b[01;34mbin\x1b[0m\n" +
+				"└── notes.txt\n\n" +
+				"1 directory, 3 files", true},
+		{"tree -n -L 1 c", "-n turns them off",
+			"c\n" +
+				"│── a.tar.gz\n" +
+				"│── b.gz\n" +
+				"│── bin\n" +
+				"└── notes.txt\n\n" +
+				"1 directory, 3 files", true},
+		{"tree -C -J -L 1 c/bin", "JSON is never colored",
+			"[\n" +
+				"  {\"type\":\"directory\",\"name\":\"c/bin\",\"contents\":[\n" +
+				"    {\"type\":\"file\",\"name\":\"run\"}\n" +
+				"  ]}\n,\n" +
+				"  {\"type\":\"report\",\"directories\":0,\"files\":1}\n]", true},
+	}
+
+	for _, tc := range tests {
+		config := mustParse(t, tc.cmd)
+		config.FS = fsys
+		config.Terminal = tc.terminal
+		assert.Equal(t, tc.want, ListDirAndFiles(config), tc.desc)
+	}
+}
+
+func TestLSColorsCode(t *testing.T) {
+	c := parseLSColors(defaultLSColors)
+	tests := []struct {
+		n    *Node
+		want string
+	}{
+		{&Node{Name: "d", Type: Directory}, "01;34"},
+		{&Node{Name: "l", Type: Link}, "01;36"},
+		{&Node{Name: "l", Type: Link, Broken: true}, "40;31;01"},
+		{&Node{Name: "p", Mode: fs.ModeNamedPipe}, "40;33"},
+		{&Node{Name: "x.ZIP", Mode: 0644}, ""},
+		{&Node{Name: "x.zip", Type: Archive, Mode: 0644}, "01;31"},
+		{&Node{Name: "x.sh", Mode: 0755}, "01;32"},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, c.code(tc.n), tc.n.Name)
+	}
+
+	c = parseLSColors("ln=target:di=34:fi=0:*.md=33")
+	assert.Equal(t, "34", c.code(&Node{Name: "l", Type: Link, linkDir: true}))
+	assert.Equal(t, "33", c.code(&Node{Name: "l.md", Type: Link}))
+	assert.Equal(t, "0", c.code(&Node{Name: "f", Mode: 0644}))
+}
@@ -0,0 +1,73 @@
+package tree
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrHelp is returned by ParseCommand when --help is given.
+	ErrHelp = errors.New("help requested")
+
+	ErrUnknownCommand  = errors.New("command not found")
+	ErrUnknownOption   = errors.New("unknown option")
+	ErrMissingArgument = errors.New("missing argument")
+	ErrInvalidValue    = errors.New("invalid value")
+	ErrConflict        = errors.New("conflicting options")
+)
+
+// ParseError describes a command line ParseCommand rejects, or options
+// NewConfig rejects. Err is one of ErrUnknownCommand, ErrUnknownOption,
+// ErrMissingArgument, ErrInvalidValue or ErrConflict, so callers can tell
+// them apart with errors.Is.
+type ParseError struct {
+	Err    error
+	Option string // the option, or the command for ErrUnknownCommand
+	Value  string // the rejected value, for ErrInvalidValue
+	Want   string // what the option accepts, for ErrInvalidValue
+	Other  string // the option Option conflicts with, for ErrConflict
+}
+
+func (e *ParseError) Error() string {
+	switch e.Err {
+	case ErrUnknownCommand:
+		return fmt.Sprintf("command not found: `%v`", e.Option)
+	case ErrUnknownOption:
+		return fmt.Sprintf("unknown option `%v`", e.Option)
+	case ErrMissingArgument:
+		return fmt.Sprintf("option `%v` requires an argument", e.Option)
+	case ErrConflict:
+		return fmt.Sprintf("option `%v` conflicts with `%v`", e.Option, e.Other)
+	}
+	msg := fmt.Sprintf("invalid value `%v` for option `%v`", e.Value, e.Option)
+	if e.Want != "" {
+		msg += ", want " + e.Want
+	}
+	return msg
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+func invalidValue(option, value, want string) error {
+	return &ParseError{Err: ErrInvalidValue, Option: option, Value: value, Want: want}
+}
+
+// WalkError is returned by RenderTree and WriteTree when parts of the tree
+// could not be read. The tree is still rendered in full, with each failure
+// noted on the entry it affects; Errs holds the failures in walk order.
+type WalkError struct {
+	Errs []error
+}
+
+func (e *WalkError) Error() string {
+	if len(e.Errs) == 1 {
+		return e.Errs[0].Error()
+	}
+	return fmt.Sprintf("%v (and %d more errors)", e.Errs[0], len(e.Errs)-1)
+}
+
+func (e *WalkError) Unwrap() []error {
+	return e.Errs
+}
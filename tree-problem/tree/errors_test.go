@@ -0,0 +1,109 @@
+package tree
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// deniedFS refuses to read the directories in denied.
+type deniedFS struct {
+	fstest.MapFS
+	denied map[string]bool
+}
+
+func (d deniedFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if d.denied[name] {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return d.MapFS.ReadDir(name)
+}
+
+func TestWalkErrors(t *testing.T) {
+	fsys := deniedFS{
+		MapFS: fstest.MapFS{
+			"top":             dir(0),
+			"top/locked":      dir(1),
+			"top/locked/x":    file(2),
+			"top/open":        dir(3),
+			"top/open/shut":   dir(4),
+			"top/open/shut/y": file(5),
+			"top/z":           file(6),
+		},
+		denied: map[string]bool{"top/locked": true, "top/open/shut": true},
+	}
+
+	tests := []test{
+		{cmd: "tree top missing", desc: "failures are noted on their entries",
+			want: "top\n" +
+				"│── locked [error opening dir]\n" +
+				"│── open\n" +
+				"│   └── shut [error opening dir]\n" +
+				"└── z\n" +
+				"missing [error opening dir]\n\n" +
+				"top: 3 directories, 1 file\n" +
+				"missing: 0 directories, 0 files\n" +
+				"3 directories, 1 file"},
+		{cmd: "tree --prune --jobs 2 top", desc: "unreadable directories are not pruned",
+			want: "top\n" +
+				"│── locked [error opening dir]\n" +
+				"│── open\n" +
+				"│   └── shut [error opening dir]\n" +
+				"└── z\n\n" +
+				"3 directories, 1 file"},
+		{cmd: "tree -J top", desc: "JSON error field",
+			want: "[\n  {\"type\":\"directory\",\"name\":\"top\",\"contents\":[\n" +
+				"    {\"type\":\"directory\",\"name\":\"locked\",\"error\":\"error opening dir\",\"contents\":[\n    ]},\n" +
+				"    {\"type\":\"directory\",\"name\":\"open\",\"contents\":[\n" +
+				"      {\"type\":\"directory\",\"name\":\"shut\",\"error\":\"error opening dir\",\"contents\":[\n      ]}\n    ]},\n" +
+				"    {\"type\":\"file\",\"name\":\"z\"}\n  ]}\n,\n" +
+				"  {\"type\":\"report\",\"directories\":3,\"files\":1}\n]"},
+		{cmd: "tree -X missing", desc: "XML error attribute",
+			want: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<tree>\n" +
+				"  <directory name=\"missing\" error=\"error opening dir\">\n  </directory>\n" +
+				"  <report>\n    <directories>0</directories>\n    <files>0</files>\n  </report>\n</tree>"},
+	}
+
+	for _, tc := range tests {
+		config := mustParse(t, tc.cmd)
+		config.FS = fsys
+		var sb strings.Builder
+		err := WriteTree(&sb, config)
+		assert.Equal(t, tc.want, strings.TrimSuffix(sb.String(), NewLine), tc.desc)
+
+		var walkErr *WalkError
+		if assert.True(t, errors.As(err, &walkErr), tc.desc) {
+			assert.True(t, errors.Is(err, fs.ErrPermission) || errors.Is(err, fs.ErrNotExist), tc.desc)
+		}
+	}
+}
+
+func TestWalkErrorsDiskUsage(t *testing.T) {
+	fsys := deniedFS{
+		MapFS:  fstest.MapFS{"a": dir(0), "a/b": dir(1), "a/b/c": dir(2)},
+		denied: map[string]bool{"a/b/c": true},
+	}
+	config := mustParse(t, "tree --du -L 1 a")
+	config.FS = fsys
+	err := WriteTree(&strings.Builder{}, config)
+	var walkErr *WalkError
+	if assert.True(t, errors.As(err, &walkErr)) {
+		assert.Equal(t, []string{"open a/b/c: permission denied"}, errorStrings(walkErr.Errs))
+	}
+
+	config = mustParse(t, "tree -L 1 a/b")
+	config.FS = fsys
+	assert.NoError(t, WriteTree(&strings.Builder{}, config), "directories beyond the level are not read")
+}
+
+func errorStrings(errs []error) []string {
+	s := make([]string, len(errs))
+	for i, err := range errs {
+		s[i] = err.Error()
+	}
+	return s
+}
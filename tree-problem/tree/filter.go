@@ -0,0 +1,120 @@
+package tree
+
+import (
+	"io/fs"
+	pathpkg "path"
+	"strings"
+)
+
+// filterEntries drops the entries of dir that are hidden, excluded by -d,
+// or rejected by the -P/-I patterns or .gitignore rules.
+func (wk *walker) filterEntries(dir *Node, files []fs.DirEntry) []fs.DirEntry {
+	c := wk.config
+	return keepEntries(files, func(f fs.DirEntry) bool {
+		if !c.hidden.shows(f.Name()) {
+			return false
+		}
+
+		isDir := f.IsDir() || wk.isArchiveEntry(dir, f) || isLinkToDir(f)
+		if c.reqOnlyDir && !isDir {
+			return false
+		}
+
+		if c.ignorePattern != "" && matchPattern(c.ignorePattern, f.Name(), c.ignoreCase) {
+			return false
+		}
+		if c.gitignore && dir.ignore.ignored(dir.fsPath, f.Name(), f.IsDir()) {
+			return false
+		}
+		// directories are always descended so matches below them are found
+		if c.matchPattern == "" || isDir || dir.matched {
+			return true
+		}
+		return matchPattern(c.matchPattern, f.Name(), c.ignoreCase)
+	})
+}
+
+func keepEntries(files []fs.DirEntry, keep func(fs.DirEntry) bool) []fs.DirEntry {
+	kept := make([]fs.DirEntry, 0, len(files))
+	for _, f := range files {
+		if keep(f) {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// matchPattern reports whether name matches any of the '|' separated
+// wildcard patterns (*, ? and [...] classes).
+func matchPattern(pattern, name string, ignoreCase bool) bool {
+	if ignoreCase {
+		pattern, name = strings.ToLower(pattern), strings.ToLower(name)
+	}
+	for _, p := range strings.Split(pattern, "|") {
+		if ok, _ := pathpkg.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func validPattern(pattern string) bool {
+	for _, p := range strings.Split(pattern, "|") {
+		if _, err := pathpkg.Match(p, ""); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// HiddenPolicy decides which entries starting with a dot are listed.
+type HiddenPolicy int
+
+const (
+	HiddenNone   HiddenPolicy = iota // no dotfiles, the default
+	HiddenAll                        // every dotfile (-a)
+	HiddenConfig                     // dotfiles except VCS metadata, editor state and caches
+)
+
+// hiddenNoise lists the dotfiles --hidden=config keeps out of the listing.
+var hiddenNoise = map[string]bool{
+	".git": true, ".hg": true, ".svn": true, ".bzr": true,
+	".DS_Store": true, ".idea": true, ".vscode": true,
+	".cache": true, ".venv": true, ".tox": true, ".mypy_cache": true, ".pytest_cache": true,
+	".gradle": true, ".terraform": true, ".next": true, ".nuxt": true, ".sass-cache": true,
+}
+
+func ParseHiddenPolicy(s string) (HiddenPolicy, bool) {
+	switch s {
+	case "none":
+		return HiddenNone, true
+	case "all":
+		return HiddenAll, true
+	case "config":
+		return HiddenConfig, true
+	}
+	return HiddenNone, false
+}
+
+func (h HiddenPolicy) String() string {
+	switch h {
+	case HiddenAll:
+		return "all"
+	case HiddenConfig:
+		return "config"
+	}
+	return "none"
+}
+
+func (h HiddenPolicy) shows(name string) bool {
+	if !strings.HasPrefix(name, ".") {
+		return true
+	}
+	switch h {
+	case HiddenAll:
+		return true
+	case HiddenConfig:
+		return !hiddenNoise[name]
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package tree
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatternFiltering(t *testing.T) {
+	fsys := fstest.MapFS{
+		"repo":                     dir(0),
+		"repo/main.go":             file(1),
+		"repo/README.md":           file(2),
+		"repo/docs":                dir(3),
+		"repo/docs/intro.md":       file(4),
+		"repo/vendor":              dir(5),
+		"repo/vendor/lib.go":       file(6),
+		"repo/node_modules":        dir(7),
+		"repo/node_modules/pkg.js": file(8),
+		"repo/pkg":                 dir(9),
+		"repo/pkg/Util.GO":         file(10),
+	}
+
+	tests := []test{
+		{cmd: "tree -P *.go repo", desc: "-P keeps matching files and all directories",
+			want: "repo\n" +
+				"│── docs\n" +
+				"│── main.go\n" +
+				"│── node_modules\n" +
+				"│── pkg\n" +
+				"└── vendor\n" +
+				"    └── lib.go\n\n" +
+				"4 directories, 2 files"},
+		{cmd: "tree -P *.go --ignore-case --prune repo", desc: "--prune drops directories left empty",
+			want: "repo\n" +
+				"│── main.go\n" +
+				"│── pkg\n" +
+				"│   └── Util.GO\n" +
+				"└── vendor\n" +
+				"    └── lib.go\n\n" +
+				"2 directories, 3 files"},
+		{cmd: "tree -I vendor|node_modules repo", desc: "-I alternation hides directories",
+			want: "repo\n" +
+				"│── README.md\n" +
+				"│── docs\n" +
+				"│   └── intro.md\n" +
+				"│── main.go\n" +
+				"└── pkg\n" +
+				"    └── Util.GO\n\n" +
+				"2 directories, 4 files"},
+		{cmd: "tree -P doc? --matchdirs --prune repo", desc: "--matchdirs lists everything under a matching directory",
+			want: "repo\n" +
+				"└── docs\n" +
+				"    └── intro.md\n\n" +
+				"1 directory, 1 file"},
+		{cmd: "tree -J -P *.md -I README* --prune repo", desc: "filters apply to JSON output and counts",
+			want: "[\n  {\"type\":\"directory\",\"name\":\"repo\",\"contents\":[\n" +
+				"    {\"type\":\"directory\",\"name\":\"docs\",\"contents\":[\n" +
+				"      {\"type\":\"file\",\"name\":\"intro.md\"}\n" +
+				"    ]}\n  ]}\n,\n" +
+				"  {\"type\":\"report\",\"directories\":1,\"files\":1}\n]"},
+	}
+
+	for _, tc := range tests {
+		config := mustParse(t, tc.cmd)
+		config.FS = fsys
+		assert.Equal(t, tc.want, ListDirAndFiles(config), tc.desc)
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	assert.True(t, matchPattern("*.go|*.md", "README.md", false))
+	assert.False(t, matchPattern("*.go", "main.GO", false))
+	assert.True(t, matchPattern("*.go", "main.GO", true))
+	assert.True(t, matchPattern("file[0-9]", "file7", false))
+	assert.False(t, validPattern("[a-"))
+}
+
+func TestHiddenPolicy(t *testing.T) {
+	fsys := fstest.MapFS{
+		"proj":                       dir(0),
+		"proj/.env.example":          file(1),
+		"proj/.git/HEAD":             file(2),
+		"proj/.github/workflows/ci":  file(3),
+		"proj/.idea/workspace.xml":   file(4),
+		"proj/main.go":               file(5),
+		"proj/.github/workflows/.ok": file(6),
+	}
+
+	tests := []test{
+		{cmd: "tree -L 1 proj", desc: "dotfiles are hidden by default",
+			want: "proj\n" +
+				"└── main.go\n\n" +
+				"0 directories, 1 file"},
+		{cmd: "tree -a -L 1 proj", desc: "-a lists every dotfile",
+			want: "proj\n" +
+				"│── .env.example\n" +
+				"│── .git\n" +
+				"│── .github\n" +
+				"│── .idea\n" +
+				"└── main.go\n\n" +
+				"3 directories, 2 files"},
+		{cmd: "tree --hidden=config proj", desc: "config policy skips VCS and editor state",
+			want: "proj\n" +
+				"│── .env.example\n" +
+				"│── .github\n" +
+				"│   └── workflows\n" +
+				"│       │── .ok\n" +
+				"│       └── ci\n" +
+				"└── main.go\n\n" +
+				"2 directories, 4 files"},
+		{cmd: "tree -J -a -d proj", desc: "hidden directories in JSON",
+			want: "[\n  {\"type\":\"directory\",\"name\":\"proj\",\"contents\":[\n" +
+				"    {\"type\":\"directory\",\"name\":\".git\",\"contents\":[\n    ]},\n" +
+				"    {\"type\":\"directory\",\"name\":\".github\",\"contents\":[\n" +
+				"      {\"type\":\"directory\",\"name\":\"workflows\",\"contents\":[\n      ]}\n    ]},\n" +
+				"    {\"type\":\"directory\",\"name\":\".idea\",\"contents\":[\n    ]}\n  ]}\n,\n" +
+				"  {\"type\":\"report\",\"directories\":4}\n]"},
+	}
+
+	for _, tc := range tests {
+		config := mustParse(t, tc.cmd)
+		config.FS = fsys
+		assert.Equal(t, tc.want, ListDirAndFiles(config), tc.desc)
+	}
+}
@@ -0,0 +1,81 @@
+package tree
+
+import (
+	"io/fs"
+	"os"
+	pathpkg "path"
+	"strings"
+)
+
+// osFS exposes the OS file system as an fs.FS. Unlike os.DirFS it is not
+// rooted, so relative paths with ".." and absolute paths keep working.
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+// readDirUnsorted returns the entries of name in the order the OS lists them.
+func (osFS) readDirUnsorted(name string) ([]fs.DirEntry, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.ReadDir(-1)
+}
+
+func (osFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFS) Lstat(name string) (fs.FileInfo, error) {
+	return os.Lstat(name)
+}
+
+func (osFS) ReadLink(name string) (string, error) {
+	return os.Readlink(name)
+}
+
+// readDir lists name in fsys, in directory order when unsorted is set and
+// fsys is able to provide it.
+func readDir(fsys fs.FS, name string, unsorted bool) ([]fs.DirEntry, error) {
+	if osfs, ok := fsys.(osFS); ok && unsorted {
+		return osfs.readDirUnsorted(name)
+	}
+	return fs.ReadDir(fsys, name)
+}
+
+func isOS(fsys fs.FS) bool {
+	_, ok := fsys.(osFS)
+	return ok
+}
+
+// joinPath joins name to the directory dir of fsys. OS paths keep the form
+// the user typed them in, fs.FS paths are kept valid.
+func joinPath(fsys fs.FS, dir, name string) string {
+	if isOS(fsys) {
+		return childPath(dir, name)
+	}
+	return pathpkg.Join(dir, name)
+}
+
+// childPath joins name to the directory path dir, which already ends in a
+// separator when it is the root "/".
+func childPath(dir, name string) string {
+	if strings.HasSuffix(dir, PathSeperator) {
+		return dir + name
+	}
+	return dir + PathSeperator + name
+}
+
+func (c TreeConfig) fileSystem() fs.FS {
+	if c.FS == nil {
+		return osFS{}
+	}
+	return c.FS
+}
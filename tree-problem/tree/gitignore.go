@@ -0,0 +1,247 @@
+package tree
+
+import (
+	"io/fs"
+	pathpkg "path"
+	"path/filepath"
+	"strings"
+)
+
+// gitignore holds the ignore rules in effect for a directory: the rules of
+// its parent followed by those loaded from the directory itself. As in git,
+// the last matching rule decides.
+type gitignore struct {
+	rules []ignoreRule
+}
+
+type ignoreRule struct {
+	base     string   // slash separated directory the rule is relative to
+	segments []string // pattern split on '/'
+	negate   bool     // !pattern re-includes a match
+	dirOnly  bool     // pattern/ only matches directories
+	anchored bool     // a '/' in the pattern anchors it to base
+	prefix   string   // path from the rule's own directory down to base
+}
+
+// load returns g extended with the rules of dir's .git/info/exclude and
+// .gitignore files, or g itself when dir has neither.
+func (g *gitignore) load(fsys fs.FS, dir string, c *calls) *gitignore {
+	return g.loadAt(fsys, dir, filepath.ToSlash(dir), "", c)
+}
+
+// loadAt is load for rules matched below base as if prefix led to it: those
+// of a directory above the walk root.
+func (g *gitignore) loadAt(fsys fs.FS, dir, base, prefix string, c *calls) *gitignore {
+	var rules []ignoreRule
+	gitDir := joinPath(fsys, dir, ".git")
+	c.stat.Add(1)
+	if fi, err := fs.Stat(fsys, gitDir); err == nil && fi.IsDir() {
+		exclude := joinPath(fsys, joinPath(fsys, gitDir, "info"), "exclude")
+		c.open.Add(1)
+		rules = append(rules, readIgnoreFile(fsys, exclude, base)...)
+	}
+	c.open.Add(1)
+	rules = append(rules, readIgnoreFile(fsys, joinPath(fsys, dir, ".gitignore"), base)...)
+	if len(rules) == 0 {
+		return g
+	}
+	for i := range rules {
+		rules[i].prefix = prefix
+	}
+
+	var inherited []ignoreRule
+	if g != nil {
+		inherited = g.rules[:len(g.rules):len(g.rules)]
+	}
+	return &gitignore{rules: append(inherited, rules...)}
+}
+
+// aboveRoot returns the rules the walk root dir inherits from the
+// directories above it, up to the top of its git work tree, as git applies
+// them whichever directory it runs in. It returns nil when dir is the top
+// itself or lies outside any work tree.
+func aboveRoot(fsys fs.FS, dir string, c *calls) *gitignore {
+	abs := pathpkg.Clean(dir)
+	up := pathpkg.Dir
+	if isOS(fsys) {
+		var err error
+		if abs, err = filepath.Abs(dir); err != nil {
+			return nil
+		}
+		up = filepath.Dir
+	}
+
+	var above []string // nearest first
+	for d := abs; ; d = above[len(above)-1] {
+		c.stat.Add(1)
+		if _, err := fs.Stat(fsys, joinPath(fsys, d, ".git")); err == nil {
+			break
+		}
+		if up(d) == d {
+			return nil
+		}
+		above = append(above, up(d))
+	}
+
+	var g *gitignore
+	base := filepath.ToSlash(dir)
+	for i := len(above) - 1; i >= 0; i-- {
+		g = g.loadAt(fsys, above[i], base, relativePath(above[i], abs), c)
+	}
+	return g
+}
+
+// relativePath returns the slash separated path of dir below its ancestor.
+func relativePath(ancestor, dir string) string {
+	rel, err := filepath.Rel(ancestor, dir)
+	if err != nil {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// ignored reports whether the entry name of directory dir is ignored.
+func (g *gitignore) ignored(dir, name string, isDir bool) bool {
+	if g == nil {
+		return false
+	}
+
+	full := filepath.ToSlash(dir) + "/" + name
+	ignored := false
+	for _, r := range g.rules {
+		if r.match(full, name, isDir) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+func readIgnoreFile(fsys fs.FS, name, base string) []ignoreRule {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil
+	}
+
+	var rules []ignoreRule
+	for _, line := range strings.Split(string(data), NewLine) {
+		if r, ok := parseIgnoreRule(base, line); ok {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+func parseIgnoreRule(base, line string) (ignoreRule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	// trailing spaces are dropped unless escaped with a backslash
+	trimmed := strings.TrimRight(line, Space)
+	if strings.HasSuffix(trimmed, "\\") && len(trimmed) < len(line) {
+		trimmed += Space
+	}
+	line = trimmed
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	r := ignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, Slash) {
+		r.dirOnly = true
+		line = strings.TrimSuffix(line, Slash)
+	}
+	if strings.Contains(line, Slash) {
+		r.anchored = true
+		line = strings.TrimPrefix(line, Slash)
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+	r.segments = strings.Split(line, Slash)
+	for i, seg := range r.segments {
+		r.segments[i] = negateClasses(seg)
+	}
+	return r, true
+}
+
+// negateClasses rewrites the negated classes [!...] of a gitignore pattern
+// into the [^...] form path.Match understands; to path.Match, [!...] is a
+// class holding '!'.
+func negateClasses(pattern string) string {
+	if !strings.Contains(pattern, "[!") {
+		return pattern
+	}
+	var b strings.Builder
+	inClass := false
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '\\' && i+1 < len(pattern):
+			b.WriteByte(c)
+			i++
+			c = pattern[i]
+		case c == '[' && !inClass:
+			inClass = true
+			if i+1 < len(pattern) && pattern[i+1] == '!' {
+				b.WriteString("[^")
+				i++
+				continue
+			}
+		case c == ']' && inClass:
+			inClass = false
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func (r ignoreRule) match(full, name string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+
+	if !r.anchored {
+		ok, _ := pathpkg.Match(r.segments[0], name)
+		return ok
+	}
+
+	rel := strings.TrimPrefix(full, r.base+Slash)
+	if r.base == "." {
+		rel = strings.TrimPrefix(full, "./")
+	}
+	if r.prefix != "" {
+		rel = r.prefix + Slash + rel
+	}
+	return matchSegments(r.segments, strings.Split(rel, Slash))
+}
+
+// matchSegments matches path segments against pattern segments, where a
+// "**" segment matches any number of path segments.
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			if len(rest) == 0 {
+				// a trailing "/**" matches everything inside
+				return len(parts) > 0
+			}
+			for i := range parts {
+				if matchSegments(rest, parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := pathpkg.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
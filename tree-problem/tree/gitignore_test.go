@@ -0,0 +1,127 @@
+package tree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitignore(t *testing.T) {
+	fsys := fstest.MapFS{
+		"repo":                    dir(0),
+		"repo/.git":               dir(0),
+		"repo/.git/info/exclude":  &fstest.MapFile{Data: []byte("*.swp\n")},
+		"repo/.gitignore":         &fstest.MapFile{Data: []byte("# build output\n/build\n*.log\n!keep.log\ncache/\ndocs/**/*.tmp\n")},
+		"repo/build":              dir(1),
+		"repo/build/app":          file(2),
+		"repo/main.go":            file(3),
+		"repo/main.go.swp":        file(4),
+		"repo/debug.log":          file(5),
+		"repo/keep.log":           file(6),
+		"repo/cache":              file(7),
+		"repo/docs":               dir(8),
+		"repo/docs/a/b/draft.tmp": file(9),
+		"repo/docs/a/b/guide.md":  file(10),
+		"repo/src":                dir(11),
+		"repo/src/.gitignore":     &fstest.MapFile{Data: []byte("!debug.log\ngen/\n")},
+		"repo/src/build":          dir(12),
+		"repo/src/build/out.o":    file(13),
+		"repo/src/cache":          dir(14),
+		"repo/src/cache/x":        file(15),
+		"repo/src/debug.log":      file(16),
+		"repo/src/trace.log":      file(16),
+		"repo/src/util.c.swp":     file(16),
+		"repo/src/gen":            dir(17),
+		"repo/src/gen/types.go":   file(18),
+	}
+
+	config := mustParse(t, "tree --gitignore repo")
+	config.FS = fsys
+	want := "repo\n" +
+		"│── cache\n" +
+		"│── docs\n" +
+		"│   └── a\n" +
+		"│       └── b\n" +
+		"│           └── guide.md\n" +
+		"│── keep.log\n" +
+		"│── main.go\n" +
+		"└── src\n" +
+		"    │── build\n" +
+		"    │   └── out.o\n" +
+		"    └── debug.log\n\n" +
+		"5 directories, 6 files"
+	assert.Equal(t, want, ListDirAndFiles(config))
+
+	config = mustParse(t, "tree repo")
+	config.FS = fsys
+	assert.Contains(t, ListDirAndFiles(config), "debug.log")
+
+	// the rules above a walk started inside the repo still apply
+	config = mustParse(t, "tree --gitignore repo/src")
+	config.FS = fsys
+	want = "repo/src\n" +
+		"│── build\n" +
+		"│   └── out.o\n" +
+		"└── debug.log\n\n" +
+		"1 directory, 2 files"
+	assert.Equal(t, want, ListDirAndFiles(config))
+
+	config = mustParse(t, "tree --gitignore repo/docs/a")
+	config.FS = fsys
+	assert.Equal(t, "repo/docs/a\n└── b\n    └── guide.md\n\n1 directory, 1 file", ListDirAndFiles(config))
+}
+
+func TestGitignoreAboveRootOS(t *testing.T) {
+	top := t.TempDir()
+	for name, data := range map[string]string{
+		".git/info/exclude": "*.swp\n",
+		".gitignore":        "*.log\nbuild/\n",
+		"sub/a.log":         "",
+		"sub/a.swp":         "",
+		"sub/b.txt":         "",
+		"sub/build/out":     "",
+	} {
+		path := filepath.Join(top, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+	}
+
+	sub := filepath.Join(top, "sub")
+	config := mustParse(t, "tree --gitignore --noreport "+sub)
+	assert.Equal(t, sub+"\n└── b.txt\n", ListDirAndFiles(config)+"\n")
+
+	// outside any work tree, the .gitignore files above do not apply
+	loose := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(loose, ".gitignore"), []byte("*.txt\n"), 0o644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(loose, "sub"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(loose, "sub", "b.txt"), nil, 0o644))
+	config = mustParse(t, "tree --gitignore --noreport "+filepath.Join(loose, "sub"))
+	assert.Contains(t, ListDirAndFiles(config), "b.txt")
+}
+
+func TestParseIgnoreRule(t *testing.T) {
+	r, ok := parseIgnoreRule(".", "/a/**/b/ ")
+	assert.True(t, ok)
+	assert.Equal(t, ignoreRule{base: ".", segments: []string{"a", "**", "b"}, dirOnly: true, anchored: true}, r)
+
+	_, ok = parseIgnoreRule(".", "# comment")
+	assert.False(t, ok)
+
+	r, _ = parseIgnoreRule("x", "\\!important")
+	assert.False(t, r.negate)
+	assert.True(t, r.match("x/!important", "!important", false))
+
+	r, _ = parseIgnoreRule(".", "*.[!o]")
+	assert.True(t, r.match("a.c", "a.c", false), "[!o] is a negated class")
+	assert.False(t, r.match("a.o", "a.o", false))
+	assert.True(t, r.match("a.!", "a.!", false))
+	r, _ = parseIgnoreRule(".", "\\[!x]")
+	assert.True(t, r.match("[!x]", "[!x]", false), "an escaped bracket starts no class")
+
+	assert.True(t, matchSegments([]string{"**", "foo"}, []string{"a", "b", "foo"}))
+	assert.True(t, matchSegments([]string{"a", "**", "b"}, []string{"a", "b"}))
+	assert.False(t, matchSegments([]string{"a", "**"}, []string{"a"}))
+}
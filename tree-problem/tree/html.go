@@ -0,0 +1,130 @@
+package tree
+
+import (
+	"html"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultHTMLTitle is the page title used when --title is not given.
+const DefaultHTMLTitle = "Directory Tree"
+
+const htmlHead = `<!DOCTYPE html>
+<html>
+<head>
+ <meta charset="utf-8">
+ <title>%title%</title>
+ <style>
+  ul.tree, ul.tree ul { list-style: none; margin: 0; padding-left: 1.5em; }
+  ul.tree summary { cursor: pointer; }
+  ul.tree .meta, ul.tree .target { color: #777; }
+  ul.tree .error { color: #c00; }
+  p.report { color: #777; }
+ </style>
+</head>
+<body>
+ <h1>%title%</h1>
+ <ul class="tree">
+`
+
+// htmlRenderer writes a standalone page of nested lists. Directories are
+// collapsible and every entry links to its path under the base HREF.
+type htmlRenderer struct {
+	tw     *treeWriter
+	config TreeConfig
+	root   string // path of the root being rendered
+}
+
+func NewHTMLRenderer(w io.Writer, config TreeConfig) Renderer {
+	return &htmlRenderer{tw: &treeWriter{w: w}, config: config}
+}
+
+func (h *htmlRenderer) Begin() error {
+	title := h.config.htmlTitle
+	if title == "" {
+		title = DefaultHTMLTitle
+	}
+	h.tw.print(strings.ReplaceAll(htmlHead, "%title%", html.EscapeString(title)))
+	return h.tw.err
+}
+
+func (h *htmlRenderer) Entry(n *Node, pos Position) error {
+	if pos.Depth == 0 {
+		h.root = n.Path
+	}
+	indent := strings.Repeat(Space, 2*pos.Depth+2)
+	h.tw.print(indent, "<li>")
+	if n.HasContents() {
+		h.tw.print("<details open><summary>", h.label(n, pos), "</summary>", NewLine, indent, " <ul>", NewLine)
+		return h.tw.err
+	}
+	h.tw.print(h.label(n, pos), "</li>", NewLine)
+	return h.tw.err
+}
+
+func (h *htmlRenderer) EndDir(n *Node, pos Position) error {
+	indent := strings.Repeat(Space, 2*pos.Depth+2)
+	h.tw.print(indent, " </ul>", NewLine, indent, "</details></li>", NewLine)
+	return h.tw.err
+}
+
+func (h *htmlRenderer) End(r Report) error {
+	h.tw.print(" </ul>", NewLine)
+	if lines := reportLines(r, h.config); len(lines) > 0 {
+		for i := range lines {
+			lines[i] = html.EscapeString(lines[i])
+		}
+		h.tw.print(" <hr>\n <p class=\"report\">", strings.Join(lines, "<br>"), "</p>", NewLine)
+	}
+	h.tw.print("</body>\n</html>\n")
+	return h.tw.err
+}
+
+// label returns the metadata, the hyperlinked name and, for symbolic links,
+// the target of n.
+func (h *htmlRenderer) label(n *Node, pos Position) string {
+	var s string
+	if fields := getMetaFields(n, h.config); len(fields) > 0 && pos.Depth > 0 {
+		s = "<span class=\"meta\">" + OpenBrkt + html.EscapeString(strings.Join(fields, Space)) + CloseBrkt + "</span> "
+	}
+
+	name := n.Name
+	if h.config.reqRelPath || pos.Depth == 0 {
+		name = n.Path
+	}
+	if n.Archive != "" {
+		// entries inside archives have nothing to link to
+		s += html.EscapeString(name)
+	} else {
+		s += "<a href=\"" + html.EscapeString(h.href(n, pos)) + "\">" + html.EscapeString(name) + "</a>"
+	}
+
+	if n.Type == Link {
+		s += "<span class=\"target\">" + html.EscapeString(linkSuffix(n)) + "</span>"
+	}
+	if note := hardLinkSuffix(n, h.config); note != "" {
+		s += "<span class=\"target\">" + html.EscapeString(note) + "</span>"
+	}
+	if note := errorSuffix(n); note != "" {
+		s += "<span class=\"error\">" + html.EscapeString(note) + "</span>"
+	}
+	return s
+}
+
+// href returns the URL of n: its path relative to the root, escaped and
+// appended to the base HREF. Directories end in a slash.
+func (h *htmlRenderer) href(n *Node, pos Position) string {
+	href := strings.TrimSuffix(h.config.htmlBase, "/")
+	if pos.Depth > 0 {
+		rel := filepath.ToSlash(strings.TrimPrefix(n.Path, childPath(h.root, "")))
+		for _, segment := range strings.Split(rel, "/") {
+			href += "/" + url.PathEscape(segment)
+		}
+	}
+	if n.HasContents() || n.linkDir {
+		href += "/"
+	}
+	return href
+}
@@ -0,0 +1,75 @@
+package tree
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTML(t *testing.T) {
+	fsys := fstest.MapFS{
+		"site":                 dir(0),
+		"site/a & b":           dir(1),
+		"site/a & b/<x>.txt":   file(2),
+		"site/docs":            dir(3),
+		"site/index.html":      file(4),
+		"site/bundle.zip":      &fstest.MapFile{Data: zipData(t, "in.txt")},
+		"site/docs/read me.md": file(5),
+	}
+	head := func(title string) string {
+		return strings.ReplaceAll(htmlHead, "%title%", title)
+	}
+
+	tests := []test{
+		{"tree -H http://example.com/art/ site", "entries link below the base HREF",
+			head(DefaultHTMLTitle) +
+				"  <li><details open><summary><a href=\"http://example.com/art/\">site</a></summary>\n" +
+				"   <ul>\n" +
+				"    <li><details open><summary><a href=\"http://example.com/art/a%20&amp;%20b/\">a &amp; b</a></summary>\n" +
+				"     <ul>\n" +
+				"      <li><a href=\"http://example.com/art/a%20&amp;%20b/%3Cx%3E.txt\">&lt;x&gt;.txt</a></li>\n" +
+				"     </ul>\n" +
+				"    </details></li>\n" +
+				"    <li><a href=\"http://example.com/art/bundle.zip\">bundle.zip</a></li>\n" +
+				"    <li><details open><summary><a href=\"http://example.com/art/docs/\">docs</a></summary>\n" +
+				"     <ul>\n" +
+				"      <li><a href=\"http://example.com/art/docs/read%20me.md\">read me.md</a></li>\n" +
+				"     </ul>\n" +
+				"    </details></li>\n" +
+				"    <li><a href=\"http://example.com/art/index.html\">index.html</a></li>\n" +
+				"   </ul>\n" +
+				"  </details></li>\n" +
+				" </ul>\n <hr>\n <p class=\"report\">2 directories, 4 files</p>\n</body>\n</html>", fsys},
+		{"tree -H . --title=Q&A -d -s -L 1 site", "title, metadata and report follow the options",
+			head("Q&amp;A") +
+				"  <li><details open><summary><a href=\"./\">site</a></summary>\n" +
+				"   <ul>\n" +
+				"    <li><details open><summary><span class=\"meta\">[          0]</span> <a href=\"./a%20&amp;%20b/\">a &amp; b</a></summary>\n" +
+				"     <ul>\n" +
+				"     </ul>\n" +
+				"    </details></li>\n" +
+				"    <li><details open><summary><span class=\"meta\">[          0]</span> <a href=\"./docs/\">docs</a></summary>\n" +
+				"     <ul>\n" +
+				"     </ul>\n" +
+				"    </details></li>\n" +
+				"   </ul>\n" +
+				"  </details></li>\n" +
+				" </ul>\n <hr>\n <p class=\"report\">0 bytes used in 2 directories</p>\n</body>\n</html>", fsys},
+		{"tree -H /a --archives -P *.txt --prune site/bundle.zip", "archive members are not linked",
+			head(DefaultHTMLTitle) +
+				"  <li><details open><summary><a href=\"/a/\">site/bundle.zip</a></summary>\n" +
+				"   <ul>\n" +
+				"    <li>in.txt</li>\n" +
+				"   </ul>\n" +
+				"  </details></li>\n" +
+				" </ul>\n <hr>\n <p class=\"report\">0 directories, 1 file</p>\n</body>\n</html>", fsys},
+	}
+
+	for _, tc := range tests {
+		config := mustParse(t, tc.cmd)
+		config.FS = tc.fsys
+		assert.Equal(t, tc.want, ListDirAndFiles(config), tc.desc)
+	}
+}
@@ -0,0 +1,217 @@
+package tree
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// jsonRenderer writes the GNU tree 2.x JSON schema: an array holding one
+// object per root, with the contents of directories nested in "contents",
+// followed by a report object. Objects are encoded with encoding/json; only
+// the layout around them, one entry per line, is written by hand.
+type jsonRenderer struct {
+	tw     *treeWriter
+	config TreeConfig
+	// more is set after an entry or a directory is closed: the line ends
+	// with a comma if a sibling follows, so it is finished by whatever
+	// comes next. A walk that stops early thus still leaves valid JSON.
+	more bool
+}
+
+func NewJSONRenderer(w io.Writer, config TreeConfig) Renderer {
+	return &jsonRenderer{tw: &treeWriter{w: w}, config: config}
+}
+
+// jsonObject is a JSON object that keeps its keys in the order they were
+// added, as the GNU schema lists them.
+type jsonObject []jsonField
+
+type jsonField struct {
+	key   string
+	value any
+}
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := marshalJSON(f.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := marshalJSON(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// marshalJSON encodes v without escaping <, > and &, which need no escaping
+// outside of HTML.
+func marshalJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte(NewLine)), nil
+}
+
+func (j *jsonRenderer) Begin() error {
+	j.tw.print(OpenBrkt, NewLine)
+	return j.tw.err
+}
+
+func (j *jsonRenderer) Entry(n *Node, pos Position) error {
+	data, err := marshalJSON(j.object(n, pos))
+	if err != nil {
+		return err
+	}
+	if j.more {
+		j.tw.print(",", NewLine)
+	}
+	j.tw.print(jsonIndent(pos.Depth))
+	if pos.Depth == 0 || n.HasContents() {
+		// reopen the object to nest the contents in it
+		j.tw.print(string(data[:len(data)-1]), ",\"contents\":[", NewLine)
+		j.more = false
+		return j.tw.err
+	}
+	j.tw.print(string(data))
+	j.more = true
+	return j.tw.err
+}
+
+func (j *jsonRenderer) EndDir(n *Node, pos Position) error {
+	if j.more {
+		j.tw.print(NewLine)
+	}
+	j.tw.print(jsonIndent(pos.Depth), JSONArrEnd)
+	j.more = true
+	return j.tw.err
+}
+
+func (j *jsonRenderer) End(r Report) error {
+	if j.more {
+		j.tw.print(NewLine)
+	}
+	if j.config.noReport && r.Stats == nil && r.Truncated == "" {
+		j.tw.print("]", NewLine)
+		return j.tw.err
+	}
+
+	report := jsonObject{{"type", "report"}}
+	if !j.config.noReport {
+		report = append(report, j.counts(r)...)
+	}
+	if len(r.Roots) > 0 && !j.config.noReport {
+		roots := make([]jsonObject, len(r.Roots))
+		for i, rr := range r.Roots {
+			roots[i] = append(jsonObject{{"name", rr.Path}}, j.counts(rr.report())...)
+		}
+		report = append(report, jsonField{"roots", roots})
+	}
+	if r.Truncated != "" {
+		report = append(report, jsonField{"truncated", r.Truncated})
+	}
+	if s := r.Stats; s != nil {
+		report = append(report, jsonField{"stats", jsonObject{
+			{"readdir", s.ReadDir}, {"stat", s.Stat}, {"lstat", s.Lstat},
+			{"readlink", s.ReadLink}, {"open", s.Open}, {"elapsed", s.Elapsed.String()},
+		}})
+	}
+
+	data, err := marshalJSON(report)
+	if err != nil {
+		return err
+	}
+	j.tw.print(",", NewLine, jsonIndent(0), string(data), NewLine, "]", NewLine)
+	return j.tw.err
+}
+
+// counts returns the size and the directory and file counts of r.
+func (j *jsonRenderer) counts(r Report) jsonObject {
+	var o jsonObject
+	if j.config.showSize() {
+		o = append(o, jsonField{"size", r.Size})
+	}
+	o = append(o, jsonField{"directories", r.Directories})
+	if !j.config.reqOnlyDir {
+		o = append(o, jsonField{"files", r.Files})
+	}
+	return o
+}
+
+// object returns the attributes of n, in the order of the GNU schema.
+func (j *jsonRenderer) object(n *Node, pos Position) jsonObject {
+	if pos.Depth == 0 {
+		obj := jsonObject{{"type", "directory"}, {"name", n.Path}}
+		if note := errorNote(n); note != "" {
+			obj = append(obj, jsonField{"error", note})
+		}
+		return obj
+	}
+
+	obj := jsonObject{{"type", n.Type.String()}, {"name", n.Name}}
+	if n.Target != "" {
+		obj = append(obj, jsonField{"target", n.Target})
+	}
+	if n.Archive != "" {
+		obj = append(obj, jsonField{"archive", n.Archive})
+	}
+	if j.config.reqInode && n.ids {
+		obj = append(obj, jsonField{"inode", n.Inode})
+	}
+	if j.config.reqDevice && n.ids {
+		obj = append(obj, jsonField{"dev", n.Device})
+	}
+	if j.config.reqFilePermsn {
+		obj = append(obj, jsonField{"mode", getPermsnMode(n, true)}, jsonField{"prot", getPermsnMode(n, false)})
+	}
+	if (j.config.reqUser || j.config.reqGroup) && n.ids {
+		userName, groupName := ownerNames(n)
+		if j.config.reqUser {
+			obj = append(obj, jsonField{"user", userName})
+		}
+		if j.config.reqGroup {
+			obj = append(obj, jsonField{"group", groupName})
+		}
+	}
+	if j.config.showSize() {
+		obj = append(obj, jsonField{"size", n.Size})
+	}
+	if j.config.showTime() {
+		obj = append(obj, jsonField{"time", formatTime(nodeTime(n, j.config), j.config)})
+	}
+	if n.hardLinked(&j.config) {
+		obj = append(obj, jsonField{"links", n.Links})
+		if n.HardLink != "" {
+			obj = append(obj, jsonField{"hardlink", n.HardLink})
+		}
+	}
+	if n.Broken {
+		obj = append(obj, jsonField{"broken", true})
+	}
+	if n.Recursive {
+		obj = append(obj, jsonField{"error", "recursive, not followed"})
+	} else if note := errorNote(n); note != "" {
+		obj = append(obj, jsonField{"error", note})
+	}
+	return obj
+}
+
+// jsonIndent returns the indentation of the objects at depth.
+func jsonIndent(depth int) string {
+	return strings.Repeat(Space, 2*depth+2)
+}
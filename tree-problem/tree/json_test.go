@@ -0,0 +1,76 @@
+package tree
+
+import (
+	"encoding/json"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// awkwardFS holds names that need escaping in JSON, XML and HTML.
+func awkwardFS() fstest.MapFS {
+	return fstest.MapFS{
+		"odd":                   dir(0),
+		"odd/say \"hi\"":        file(1),
+		"odd/a&b <c>":           dir(2),
+		"odd/a&b <c>/tab\there": file(3),
+		"odd/back\\slash":       file(4),
+		"odd/line\nbreak":       file(5),
+		"odd/ünï©ødé ✓":         file(6),
+		"odd/'quote'":           file(7),
+	}
+}
+
+type jsonEntry struct {
+	Type        string
+	Name        string
+	Mode, Prot  string
+	Size        *int64
+	Time        string
+	Contents    []jsonEntry
+	Directories *int
+	Files       *int
+}
+
+func TestJSONParsesBack(t *testing.T) {
+	config := mustParse(t, "tree -J odd")
+	config.FS = awkwardFS()
+	var got []jsonEntry
+	assert.NoError(t, json.Unmarshal([]byte(ListDirAndFiles(config)), &got))
+
+	dirs, files := 1, 6
+	want := []jsonEntry{
+		{Type: "directory", Name: "odd", Contents: []jsonEntry{
+			{Type: "file", Name: "'quote'"},
+			{Type: "directory", Name: "a&b <c>", Contents: []jsonEntry{
+				{Type: "file", Name: "tab\there"},
+			}},
+			{Type: "file", Name: "back\\slash"},
+			{Type: "file", Name: "line\nbreak"},
+			{Type: "file", Name: "say \"hi\""},
+			{Type: "file", Name: "ünï©ødé ✓"},
+		}},
+		{Type: "report", Directories: &dirs, Files: &files},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestJSONIsValid(t *testing.T) {
+	cmds := []string{
+		"tree -J odd",
+		"tree -J -d odd",
+		"tree -J -p -s -D -f odd",
+		"tree -J --du -h -L 1 odd",
+		"tree -J --stats -P *e* --prune odd",
+		"tree -J -s odd odd",
+		"tree -J --noreport odd odd",
+		"tree -J --noreport --stats odd",
+	}
+	for _, cmd := range cmds {
+		config := mustParse(t, cmd)
+		config.FS = awkwardFS()
+		out := ListDirAndFiles(config)
+		assert.True(t, json.Valid([]byte(out)), "%v:\n%v", cmd, out)
+	}
+}
@@ -0,0 +1,80 @@
+package tree
+
+import (
+	"io/fs"
+	"path/filepath"
+)
+
+// ReadLinkFS is implemented by file systems that can report symbolic links.
+// It has the shape of fs.ReadLinkFS from newer Go releases.
+type ReadLinkFS interface {
+	fs.FS
+	ReadLink(name string) (string, error)
+	Lstat(name string) (fs.FileInfo, error)
+}
+
+// fileKey identifies a directory independently of the path it was reached
+// by. Device and inode numbers are used where the platform has them, the
+// resolved path otherwise.
+type fileKey struct {
+	dev, ino uint64
+	path     string
+}
+
+func newFileKey(fsys fs.FS, name string, fi fs.FileInfo) fileKey {
+	if dev, ino, ok := fileID(fi); ok {
+		return fileKey{dev: dev, ino: ino}
+	}
+	if isOS(fsys) {
+		if real, err := filepath.EvalSymlinks(name); err == nil {
+			name = real
+		}
+		if abs, err := filepath.Abs(name); err == nil {
+			name = abs
+		}
+	}
+	return fileKey{path: name}
+}
+
+// resolveLink fills in the target of the symbolic link n, read from e, and
+// decides whether the walker descends into it: only with -l, only for
+// directories, and never into a directory that is already one of its
+// ancestors.
+func (wk *walker) resolveLink(n *Node, e *entry) {
+	if target, err := e.readLink(); err == nil {
+		n.Target = target
+	}
+
+	fi, err := e.targetInfo()
+	if err != nil {
+		n.Broken = true
+		return
+	}
+	if !fi.IsDir() {
+		return
+	}
+	n.linkDir = true
+	if !wk.config.followLinks {
+		return
+	}
+
+	n.key = newFileKey(n.fsys, n.fsPath, fi)
+	for a := n.parent; a != nil; a = a.parent {
+		if a.key == n.key {
+			n.Recursive = true
+			return
+		}
+	}
+	n.follow = true
+}
+
+// isLinkToDir reports whether f is a symbolic link that resolves to a
+// directory.
+func isLinkToDir(f fs.DirEntry) bool {
+	e, ok := f.(*entry)
+	if !ok || e.Type()&fs.ModeSymlink == 0 {
+		return false
+	}
+	fi, err := e.targetInfo()
+	return err == nil && fi.IsDir()
+}
@@ -0,0 +1,110 @@
+package tree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSymlinks(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "a", "b"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "a", "f"), nil, 0644))
+	assert.NoError(t, os.Symlink("../../a", filepath.Join(root, "a", "b", "up")))
+	assert.NoError(t, os.Symlink("f", filepath.Join(root, "a", "ok")))
+	assert.NoError(t, os.Symlink("nope", filepath.Join(root, "a", "dead")))
+	assert.NoError(t, os.Symlink("a", filepath.Join(root, "top")))
+
+	tests := []struct {
+		cmd, dir, desc, want string
+	}{
+		{"tree", "", "links show their targets and are not followed",
+			root + "\n" +
+				"│── a\n" +
+				"│   │── b\n" +
+				"│   │   └── up -> ../../a\n" +
+				"│   │── dead -> nope  [broken link]\n" +
+				"│   │── f\n" +
+				"│   └── ok -> f\n" +
+				"└── top -> a\n\n" +
+				"4 directories, 3 files"},
+		{"tree -l", "", "-l follows links to directories but not into their ancestors",
+			root + "\n" +
+				"│── a\n" +
+				"│   │── b\n" +
+				"│   │   └── up -> ../../a  [recursive, not followed]\n" +
+				"│   │── dead -> nope  [broken link]\n" +
+				"│   │── f\n" +
+				"│   └── ok -> f\n" +
+				"└── top -> a\n" +
+				"    │── b\n" +
+				"    │   └── up -> ../../a  [recursive, not followed]\n" +
+				"    │── dead -> nope  [broken link]\n" +
+				"    │── f\n" +
+				"    └── ok -> f\n\n" +
+				"6 directories, 6 files"},
+		{"tree -d", "", "-d keeps links to directories",
+			root + "\n" +
+				"│── a\n" +
+				"│   └── b\n" +
+				"│       └── up -> ../../a\n" +
+				"└── top -> a\n\n" +
+				"4 directories"},
+		{"tree -J", "/a/b", "JSON carries target, broken and error attributes",
+			"[\n" +
+				"  {\"type\":\"directory\",\"name\":\"" + root + "/a/b\",\"contents\":[\n" +
+				"    {\"type\":\"link\",\"name\":\"up\",\"target\":\"../../a\"}\n" +
+				"  ]}\n,\n" +
+				"  {\"type\":\"report\",\"directories\":1,\"files\":0}\n]"},
+	}
+
+	for _, tc := range tests {
+		got := ListDirAndFiles(mustParse(t, tc.cmd+" "+root+tc.dir))
+		assert.Equal(t, tc.want, got, tc.desc)
+	}
+}
+
+func TestSymlinkGrouping(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(root, "z"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "f"), nil, 0644))
+	assert.NoError(t, os.Symlink("z", filepath.Join(root, "lnk")))
+
+	tests := []struct {
+		cmd, desc, want string
+	}{
+		{"tree --dirsfirst", "links to directories are grouped with directories",
+			root + "\n│── lnk -> z\n│── z\n└── f\n\n2 directories, 1 file"},
+		{"tree --filesfirst", "and after files with --filesfirst",
+			root + "\n│── f\n│── lnk -> z\n└── z\n\n2 directories, 1 file"},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, ListDirAndFiles(mustParse(t, tc.cmd+" "+root)), tc.desc)
+	}
+}
+
+func TestSymlinksStructured(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.Symlink(".", filepath.Join(root, "self")))
+	assert.NoError(t, os.Symlink("nope", filepath.Join(root, "dead")))
+
+	got := ListDirAndFiles(mustParse(t, "tree -l -J "+root))
+	want := "[\n" +
+		"  {\"type\":\"directory\",\"name\":\"" + root + "\",\"contents\":[\n" +
+		"    {\"type\":\"link\",\"name\":\"dead\",\"target\":\"nope\",\"broken\":true},\n" +
+		"    {\"type\":\"link\",\"name\":\"self\",\"target\":\".\",\"error\":\"recursive, not followed\"}\n" +
+		"  ]}\n,\n" +
+		"  {\"type\":\"report\",\"directories\":1,\"files\":1}\n]"
+	assert.Equal(t, want, got)
+
+	got = ListDirAndFiles(mustParse(t, "tree -l -X "+root))
+	want = "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<tree>\n" +
+		"  <directory name=\"" + root + "\">\n" +
+		"    <link name=\"dead\" target=\"nope\" broken=\"true\"></link>\n" +
+		"    <link name=\"self\" target=\".\" error=\"recursive, not followed\"></link>\n" +
+		"  </directory>\n" +
+		"  <report>\n    <directories>1</directories>\n    <files>1</files>\n  </report>\n</tree>"
+	assert.Equal(t, want, got)
+}
@@ -0,0 +1,109 @@
+package tree
+
+import (
+	"fmt"
+	"io/fs"
+	"sync/atomic"
+	"time"
+)
+
+// Stats counts the file system calls made by a walk, as reported by
+// --stats.
+type Stats struct {
+	ReadDir, Stat, Lstat, ReadLink, Open int64
+	Elapsed                              time.Duration
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf("%d readdir, %d stat, %d lstat, %d readlink, %d open calls in %v",
+		s.ReadDir, s.Stat, s.Lstat, s.ReadLink, s.Open, s.Elapsed)
+}
+
+// calls is the live, concurrency safe form of Stats.
+type calls struct {
+	readDir, stat, lstat, readLink, open atomic.Int64
+}
+
+func (c *calls) stats(elapsed time.Duration) *Stats {
+	return &Stats{
+		ReadDir:  c.readDir.Load(),
+		Stat:     c.stat.Load(),
+		Lstat:    c.lstat.Load(),
+		ReadLink: c.readLink.Load(),
+		Open:     c.open.Load(),
+		Elapsed:  elapsed,
+	}
+}
+
+// entry is a directory entry whose metadata, and that of the target of a
+// symbolic link, is read at most once and only when something asks for it.
+type entry struct {
+	fs.DirEntry
+	fsys  fs.FS
+	path  string // path of the entry inside fsys
+	calls *calls
+
+	info, target         fs.FileInfo
+	infoErr, targetErr   error
+	infoRead, targetRead bool
+}
+
+func (wk *walker) entries(fsys fs.FS, dirPath string, files []fs.DirEntry) []fs.DirEntry {
+	entries := make([]fs.DirEntry, len(files))
+	for i, f := range files {
+		entries[i] = &entry{DirEntry: f, fsys: fsys, path: joinPath(fsys, dirPath, f.Name()), calls: &wk.calls}
+	}
+	return entries
+}
+
+// Info returns the lstat information of the entry.
+func (e *entry) Info() (fs.FileInfo, error) {
+	if !e.infoRead {
+		e.calls.lstat.Add(1)
+		e.info, e.infoErr = e.DirEntry.Info()
+		e.infoRead = true
+	}
+	return e.info, e.infoErr
+}
+
+// targetInfo returns the stat information of the entry, following it when it
+// is a symbolic link.
+func (e *entry) targetInfo() (fs.FileInfo, error) {
+	if e.Type()&fs.ModeSymlink == 0 {
+		return e.Info()
+	}
+	if !e.targetRead {
+		e.calls.stat.Add(1)
+		e.target, e.targetErr = fs.Stat(e.fsys, e.path)
+		e.targetRead = true
+	}
+	return e.target, e.targetErr
+}
+
+func (e *entry) readLink() (string, error) {
+	rl, ok := e.fsys.(ReadLinkFS)
+	if !ok {
+		return "", fs.ErrInvalid
+	}
+	e.calls.readLink.Add(1)
+	return rl.ReadLink(e.path)
+}
+
+// needInfo reports whether nodes have to carry their mode, size and times;
+// otherwise the type reported by the directory listing is enough.
+func (wk *walker) needInfo() bool {
+	c := wk.config
+	switch c.sortKey {
+	case SortSize, SortModTime, SortChangeTime:
+		return true
+	}
+	return wk.keep || c.reqFilePermsn || c.reqUser || c.reqGroup || c.reqInode || c.reqDevice ||
+		c.hardLinks || c.showSize() || c.showTime() || c.colorize()
+}
+
+// countLinks reports whether files with several hard links are marked and
+// counted once: with --hardlinks, and wherever sizes are shown, so that no
+// size is added up twice.
+func (c TreeConfig) countLinks() bool {
+	return c.hardLinks || c.showSize()
+}
@@ -0,0 +1,53 @@
+package tree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type reportRenderer struct {
+	discardRenderer
+	report Report
+}
+
+func (r *reportRenderer) End(report Report) error {
+	r.report = report
+	return nil
+}
+
+func TestStatsCountCalls(t *testing.T) {
+	tests := []struct {
+		cmd, desc string
+		want      Stats
+	}{
+		{"tree --stats", "names alone need no stat", Stats{ReadDir: 5, Stat: 1}},
+		{"tree --stats -t", "sorting by time stats each entry", Stats{ReadDir: 5, Stat: 1, Lstat: 7}},
+		{"tree --stats -t -p -s -D", "metadata is shared by sorting and every column", Stats{ReadDir: 5, Stat: 1, Lstat: 7}},
+		{"tree --stats -t -s --jobs 3", "workers stat each entry once too", Stats{ReadDir: 5, Stat: 1, Lstat: 7}},
+		{"tree --stats -L 1 -s", "only listed directories are read", Stats{ReadDir: 1, Stat: 1, Lstat: 2}},
+	}
+
+	for _, tc := range tests {
+		config := mustParse(t, tc.cmd+" resources/test-dir")
+		config.FS = testFS()
+		r := &reportRenderer{}
+		assert.NoError(t, RenderTree(r, config), tc.desc)
+		if assert.NotNil(t, r.report.Stats, tc.desc) {
+			got := *r.report.Stats
+			got.Elapsed = 0
+			assert.Equal(t, tc.want, got, tc.desc)
+		}
+	}
+}
+
+func TestStatsReport(t *testing.T) {
+	config := mustParse(t, "tree --stats -L 1 resources/test-dir")
+	config.FS = testFS()
+	assert.Regexp(t, `\n2 directories, 0 files\n1 readdir, 1 stat, 0 lstat, 0 readlink, 0 open calls in [0-9.]+[nµm]?s$`,
+		ListDirAndFiles(config))
+
+	config = mustParse(t, "tree -L 1 resources/test-dir")
+	config.FS = testFS()
+	assert.NotContains(t, ListDirAndFiles(config), "calls in")
+}
@@ -0,0 +1,141 @@
+package tree
+
+import (
+	"io/fs"
+	"time"
+)
+
+type NodeType int
+
+const (
+	File NodeType = iota
+	Directory
+	Link
+	Archive
+)
+
+func (t NodeType) String() string {
+	switch t {
+	case Directory:
+		return "directory"
+	case Link:
+		return "link"
+	case Archive:
+		return "archive"
+	}
+	return "file"
+}
+
+// Node is a single entry of a walked tree. Children is only populated for
+// directories and archives that were descended into. While rendering, Mode
+// holds only the type bits and Size, the times and the ids stay zero unless
+// the output or the sort order needs them; Build always fills them in.
+type Node struct {
+	Name    string
+	Path    string
+	Type    NodeType
+	Mode    fs.FileMode
+	Size    int64
+	ModTime time.Time
+	// ChangeTime is the last status change, or ModTime where the file
+	// system has none.
+	ChangeTime time.Time
+	// Inode, Device, UID and GID come from the stat information of the
+	// file system, where it has any; they stay zero otherwise.
+	Inode, Device uint64
+	UID, GID      uint32
+	Children      []*Node
+	Err           error
+	// Archive is the path of the archive the node was read from, if any.
+	Archive string
+	// Target is what a symbolic link points to. Broken is set when it
+	// does not resolve, Recursive when -l found it pointing back at one
+	// of its own ancestors.
+	Target    string
+	Broken    bool
+	Recursive bool
+	// OverLimit is the number of entries of a directory left unopened
+	// because it holds more than --filelimit.
+	OverLimit int
+	// Links is the number of hard links to the file, where the file system
+	// tells. With --hardlinks or sizes, HardLink is the path the same file
+	// was listed under before; such entries are not counted again.
+	Links    uint64
+	HardLink string
+
+	fsys    fs.FS  // file system the node was read from
+	fsPath  string // path of the node inside fsys
+	loaded  bool   // Children have been read
+	ids     bool   // Inode, Device, UID and GID are known
+	matched bool   // a directory above matched -P with --matchdirs
+	ignore  *gitignore
+	parent  *Node
+	key     fileKey       // identity of a directory, kept for -l loop detection
+	linkDir bool          // a symbolic link to a directory
+	follow  bool          // a symbolic link to a directory descended with -l
+	otherFS bool          // a directory on another file system, not opened with -x
+	links   []linkSize    // hard-linked files below a --du directory whose contents are not listed
+	done    chan struct{} // closed once Children are read, with --jobs
+}
+
+func (n *Node) IsDir() bool {
+	return n.Type == Directory
+}
+
+// HasContents reports whether the walker lists children under n, in which
+// case renderers receive an EndDir call for it.
+func (n *Node) HasContents() bool {
+	return n.Type == Directory || n.Type == Archive || n.follow
+}
+
+// device returns the device holding the entries of the directory n: that
+// of its target for a followed symbolic link.
+func (n *Node) device() (uint64, bool) {
+	if n.follow {
+		return n.key.dev, n.key.path == ""
+	}
+	return n.Device, n.ids
+}
+
+// hardLinked reports whether n is a file with more than one hard link that
+// config counts once.
+func (n *Node) hardLinked(config *TreeConfig) bool {
+	return config.countLinks() && !n.IsDir() && n.ids && n.Links > 1
+}
+
+// unopened reports whether n is a directory whose contents were left out:
+// it could not be read, exceeds --filelimit or lies on another file system.
+func (n *Node) unopened() bool {
+	return n.Err != nil || n.OverLimit > 0 || n.otherFS
+}
+
+// Build walks path with the given config and returns the fully loaded tree.
+func Build(config TreeConfig, path string) *Node {
+	wk := newWalker(&config, discardRenderer{})
+	wk.keep = true
+	defer wk.startJobs()()
+	root := wk.newRoot(path)
+	_ = wk.visit(root, Position{Last: true}) // discardRenderer never fails
+	return root
+}
+
+func (n *Node) setInfo(fi fs.FileInfo) {
+	n.Type = nodeType(fi.Mode())
+	n.Mode = fi.Mode()
+	n.Size = fi.Size()
+	n.ModTime = fi.ModTime()
+	n.ChangeTime = changeTime(fi)
+	n.Device, n.Inode, n.ids = fileID(fi)
+	n.UID, n.GID, _ = fileOwner(fi)
+	n.Links = fileLinks(fi)
+}
+
+func nodeType(m fs.FileMode) NodeType {
+	switch {
+	case m.IsDir():
+		return Directory
+	case m&fs.ModeSymlink != 0:
+		return Link
+	}
+	return File
+}
@@ -0,0 +1,452 @@
+package tree
+
+import (
+	"io/fs"
+	"strconv"
+	"time"
+)
+
+// Format is an output format of the tree.
+type Format int
+
+const (
+	Text Format = iota
+	JSON
+	XML
+	HTML
+)
+
+func (f Format) String() string {
+	switch f {
+	case JSON:
+		return "JSON"
+	case XML:
+		return "XML"
+	case HTML:
+		return "HTML"
+	}
+	return "Text"
+}
+
+func (c TreeConfig) format() Format {
+	switch {
+	case c.reqHtmlFormat:
+		return HTML
+	case c.reqXmlFormat:
+		return XML
+	case c.reqJsonFormat:
+		return JSON
+	}
+	return Text
+}
+
+// Option sets up a TreeConfig built by NewConfig. It fails on invalid
+// values and on choices conflicting with an earlier option.
+type Option func(*TreeConfig) error
+
+// NewConfig returns the configuration described by opts, for library users
+// that do not want to go through a command line:
+//
+//	config, err := tree.NewConfig(tree.WithPaths("src"), tree.WithLevel(2), tree.WithFormat(tree.JSON))
+//
+// Without WithPaths the current directory is listed.
+func NewConfig(opts ...Option) (TreeConfig, error) {
+	config := NewTreeConfig()
+	for _, opt := range opts {
+		if err := opt(config); err != nil {
+			return TreeConfig{}, err
+		}
+	}
+	if len(config.paths) < 1 {
+		config.paths = []string{"."}
+	}
+	return *config, nil
+}
+
+func conflict(option, other string) error {
+	return &ParseError{Err: ErrConflict, Option: option, Other: other}
+}
+
+func WithPaths(paths ...string) Option {
+	return func(c *TreeConfig) error {
+		c.paths = append(c.paths, paths...)
+		return nil
+	}
+}
+
+// WithFS lists the paths in fsys instead of the OS file system.
+func WithFS(fsys fs.FS) Option {
+	return func(c *TreeConfig) error {
+		c.FS = fsys
+		return nil
+	}
+}
+
+func WithFormat(f Format) Option {
+	return func(c *TreeConfig) error {
+		if cur := c.format(); cur != Text && cur != f {
+			return conflict("WithFormat("+f.String()+")", "WithFormat("+cur.String()+")")
+		}
+		switch f {
+		case JSON:
+			c.reqJsonFormat = true
+		case XML:
+			c.reqXmlFormat = true
+		case HTML:
+			c.reqHtmlFormat = true
+		case Text:
+		default:
+			return invalidValue("WithFormat", strconv.Itoa(int(f)), "Text, JSON, XML or HTML")
+		}
+		return nil
+	}
+}
+
+// WithHTMLBase sets the base HREF of the links of the HTML output.
+func WithHTMLBase(href string) Option {
+	return func(c *TreeConfig) error {
+		c.htmlBase = href
+		return nil
+	}
+}
+
+// WithTitle sets the title of the HTML page.
+func WithTitle(title string) Option {
+	return func(c *TreeConfig) error {
+		c.htmlTitle = title
+		return nil
+	}
+}
+
+func WithLevel(level int) Option {
+	return func(c *TreeConfig) error {
+		if level < 1 {
+			return invalidValue("WithLevel", strconv.Itoa(level), "a number greater than 0")
+		}
+		c.level = level
+		return nil
+	}
+}
+
+func DirsOnly() Option {
+	return func(c *TreeConfig) error {
+		c.reqOnlyDir = true
+		return nil
+	}
+}
+
+func FullPath() Option {
+	return func(c *TreeConfig) error {
+		c.reqRelPath = true
+		return nil
+	}
+}
+
+func NoIndent() Option {
+	return func(c *TreeConfig) error {
+		c.noIndent = true
+		return nil
+	}
+}
+
+func FollowLinks() Option {
+	return func(c *TreeConfig) error {
+		c.followLinks = true
+		return nil
+	}
+}
+
+func WithHidden(h HiddenPolicy) Option {
+	return func(c *TreeConfig) error {
+		if h < HiddenNone || h > HiddenConfig {
+			return invalidValue("WithHidden", strconv.Itoa(int(h)), "HiddenNone, HiddenAll or HiddenConfig")
+		}
+		c.hidden = h
+		return nil
+	}
+}
+
+// WithPattern lists only the files matching pattern, like -P.
+func WithPattern(pattern string) Option {
+	return func(c *TreeConfig) error {
+		if !validPattern(pattern) {
+			return invalidValue("WithPattern", pattern, "a valid pattern")
+		}
+		c.matchPattern = pattern
+		return nil
+	}
+}
+
+// WithIgnorePattern leaves out the files matching pattern, like -I.
+func WithIgnorePattern(pattern string) Option {
+	return func(c *TreeConfig) error {
+		if !validPattern(pattern) {
+			return invalidValue("WithIgnorePattern", pattern, "a valid pattern")
+		}
+		c.ignorePattern = pattern
+		return nil
+	}
+}
+
+func IgnoreCase() Option {
+	return func(c *TreeConfig) error {
+		c.ignoreCase = true
+		return nil
+	}
+}
+
+func MatchDirs() Option {
+	return func(c *TreeConfig) error {
+		c.matchDirs = true
+		return nil
+	}
+}
+
+func Prune() Option {
+	return func(c *TreeConfig) error {
+		c.prune = true
+		return nil
+	}
+}
+
+func Gitignore() Option {
+	return func(c *TreeConfig) error {
+		c.gitignore = true
+		return nil
+	}
+}
+
+// OneFileSystem lists directories on other file systems without opening them.
+func OneFileSystem() Option {
+	return func(c *TreeConfig) error {
+		c.oneFS = true
+		return nil
+	}
+}
+
+// HardLinks marks files listed under several names and counts them once,
+// as sizes do.
+func HardLinks() Option {
+	return func(c *TreeConfig) error {
+		c.hardLinks = true
+		return nil
+	}
+}
+
+func Archives() Option {
+	return func(c *TreeConfig) error {
+		c.archives = true
+		return nil
+	}
+}
+
+// WithJobs reads directories with the given number of workers.
+func WithJobs(jobs int) Option {
+	return func(c *TreeConfig) error {
+		if jobs < 1 {
+			return invalidValue("WithJobs", strconv.Itoa(jobs), "a number greater than 0")
+		}
+		c.jobs = jobs
+		return nil
+	}
+}
+
+// WithFileLimit leaves directories holding more than n entries unopened.
+func WithFileLimit(n int) Option {
+	return func(c *TreeConfig) error {
+		if n < 1 {
+			return invalidValue("WithFileLimit", strconv.Itoa(n), "a number greater than 0")
+		}
+		c.fileLimit = n
+		return nil
+	}
+}
+
+// WithMaxEntries stops the walk after n entries; see RenderTreeContext.
+func WithMaxEntries(n int) Option {
+	return func(c *TreeConfig) error {
+		if n < 1 {
+			return invalidValue("WithMaxEntries", strconv.Itoa(n), "a number greater than 0")
+		}
+		c.maxEntries = n
+		return nil
+	}
+}
+
+// WithTimeout stops the walk once d has passed; see RenderTreeContext.
+func WithTimeout(d time.Duration) Option {
+	return func(c *TreeConfig) error {
+		if d <= 0 {
+			return invalidValue("WithTimeout", d.String(), "a positive duration")
+		}
+		c.timeout = d
+		return nil
+	}
+}
+
+func WithStats() Option {
+	return func(c *TreeConfig) error {
+		c.stats = true
+		return nil
+	}
+}
+
+// NoReport leaves out the directory and file counts at the end of the listing.
+func NoReport() Option {
+	return func(c *TreeConfig) error {
+		c.noReport = true
+		return nil
+	}
+}
+
+func WithPerms() Option {
+	return func(c *TreeConfig) error {
+		c.reqFilePermsn = true
+		return nil
+	}
+}
+
+func WithUser() Option {
+	return func(c *TreeConfig) error {
+		c.reqUser = true
+		return nil
+	}
+}
+
+func WithGroup() Option {
+	return func(c *TreeConfig) error {
+		c.reqGroup = true
+		return nil
+	}
+}
+
+func WithInodes() Option {
+	return func(c *TreeConfig) error {
+		c.reqInode = true
+		return nil
+	}
+}
+
+func WithDevice() Option {
+	return func(c *TreeConfig) error {
+		c.reqDevice = true
+		return nil
+	}
+}
+
+func WithSize() Option {
+	return func(c *TreeConfig) error {
+		c.reqSize = true
+		return nil
+	}
+}
+
+// HumanSize prints sizes in powers of 1024, or of 1000 with si.
+func HumanSize(si bool) Option {
+	return func(c *TreeConfig) error {
+		c.humanSize = true
+		c.siUnits = si
+		return nil
+	}
+}
+
+// DiskUsage sizes directories by their contents, like --du.
+func DiskUsage() Option {
+	return func(c *TreeConfig) error {
+		c.du = true
+		return nil
+	}
+}
+
+func WithTime() Option {
+	return func(c *TreeConfig) error {
+		c.reqTime = true
+		return nil
+	}
+}
+
+// WithTimeFormat prints times in the strftime format layout.
+func WithTimeFormat(layout string) Option {
+	return func(c *TreeConfig) error {
+		c.timeFmt = layout
+		return nil
+	}
+}
+
+// ChangeTime sorts by status change time and prints it instead of the
+// modification time, as -c does.
+func ChangeTime() Option {
+	return func(c *TreeConfig) error {
+		if c.sortSet && c.sortKey != SortChangeTime {
+			return conflict("ChangeTime", "WithSort("+c.sortKey.String()+")")
+		}
+		c.sortKey, c.sortSet = SortChangeTime, true
+		c.changeTime = true
+		return nil
+	}
+}
+
+func WithSort(key SortKey) Option {
+	return func(c *TreeConfig) error {
+		if key < 0 || int(key) >= len(sortKeyNames) {
+			return invalidValue("WithSort", strconv.Itoa(int(key)), "a SortKey")
+		}
+		if c.sortSet && c.sortKey != key {
+			return conflict("WithSort("+key.String()+")", "WithSort("+c.sortKey.String()+")")
+		}
+		c.sortKey, c.sortSet = key, true
+		return nil
+	}
+}
+
+// SortIgnoreCase compares names without regard to case when sorting by
+// name or version.
+func SortIgnoreCase() Option {
+	return func(c *TreeConfig) error {
+		c.sortIgnoreCase = true
+		return nil
+	}
+}
+
+func Reverse() Option {
+	return func(c *TreeConfig) error {
+		c.reverse = true
+		return nil
+	}
+}
+
+func DirsFirst() Option {
+	return func(c *TreeConfig) error {
+		if c.filesFirst {
+			return conflict("DirsFirst", "FilesFirst")
+		}
+		c.dirsFirst = true
+		return nil
+	}
+}
+
+func FilesFirst() Option {
+	return func(c *TreeConfig) error {
+		if c.dirsFirst {
+			return conflict("FilesFirst", "DirsFirst")
+		}
+		c.filesFirst = true
+		return nil
+	}
+}
+
+// WithColor turns colored names on or off, whether or not the output is a
+// terminal.
+func WithColor(on bool) Option {
+	return func(c *TreeConfig) error {
+		mode := colorNever
+		if on {
+			mode = colorAlways
+		}
+		if c.color != colorAuto && c.color != mode {
+			return conflict("WithColor("+strconv.FormatBool(on)+")", "WithColor("+strconv.FormatBool(!on)+")")
+		}
+		c.color = mode
+		return nil
+	}
+}
@@ -0,0 +1,76 @@
+package tree
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewConfig(t *testing.T) {
+	tests := []struct {
+		opts []Option
+		same string // the equivalent command line
+	}{
+		{nil, "tree"},
+		{[]Option{WithPaths("a", "b"), WithLevel(2), DirsOnly()}, "tree -L 2 -d a b"},
+		{[]Option{WithFormat(JSON), WithFormat(JSON), FullPath(), NoIndent()}, "tree -J -f -i"},
+		{[]Option{WithFormat(HTML), WithHTMLBase("/x"), WithTitle("T")}, "tree -H /x --title T"},
+		{[]Option{WithPattern("*.go|*.md"), WithIgnorePattern("*_test.go"), IgnoreCase(), MatchDirs(), Prune()},
+			"tree -P *.go|*.md -I *_test.go --ignore-case --matchdirs --prune"},
+		{[]Option{WithHidden(HiddenConfig), Gitignore(), Archives(), FollowLinks(), WithJobs(4), WithStats()},
+			"tree --hidden=config --gitignore --archives -l --jobs 4 --stats"},
+		{[]Option{WithUser(), WithGroup(), WithInodes(), WithDevice()}, "tree -u -g --inodes --device"},
+		{[]Option{OneFileSystem(), HardLinks()}, "tree -x --hardlinks"},
+		{[]Option{WithMaxEntries(10), WithTimeout(time.Second), WithFileLimit(5)},
+			"tree --max-entries 10 --timeout 1s --filelimit 5"},
+		{[]Option{WithPerms(), WithSize(), HumanSize(true), DiskUsage(), WithTime(), WithTimeFormat("%F")},
+			"tree -p -s -h --si --du -D --timefmt %F"},
+		{[]Option{WithSort(SortVersion), Reverse(), DirsFirst(), WithColor(false), NoReport()},
+			"tree -v -r --dirsfirst -n --noreport"},
+		{[]Option{SortIgnoreCase()}, "tree --sort-ignore-case"},
+		{[]Option{ChangeTime(), WithTime()}, "tree -c -D"},
+		{[]Option{WithSort(SortName)}, "tree --sort name"},
+	}
+
+	for _, tc := range tests {
+		got, err := NewConfig(tc.opts...)
+		assert.NoError(t, err, tc.same)
+		assert.Equal(t, mustParse(t, tc.same), got, tc.same)
+	}
+}
+
+func TestNewConfigErrors(t *testing.T) {
+	tests := []struct {
+		opts []Option
+		kind error
+		msg  string
+	}{
+		{[]Option{WithLevel(0)}, ErrInvalidValue, "invalid value `0` for option `WithLevel`, want a number greater than 0"},
+		{[]Option{WithJobs(-2)}, ErrInvalidValue, "invalid value `-2` for option `WithJobs`, want a number greater than 0"},
+		{[]Option{WithTimeout(0)}, ErrInvalidValue, "invalid value `0s` for option `WithTimeout`, want a positive duration"},
+		{[]Option{WithPattern("[")}, ErrInvalidValue, "invalid value `[` for option `WithPattern`, want a valid pattern"},
+		{[]Option{WithFormat(Format(9))}, ErrInvalidValue, "invalid value `9` for option `WithFormat`, want Text, JSON, XML or HTML"},
+		{[]Option{WithFormat(JSON), WithFormat(XML)}, ErrConflict, "option `WithFormat(XML)` conflicts with `WithFormat(JSON)`"},
+		{[]Option{DirsFirst(), FilesFirst()}, ErrConflict, "option `FilesFirst` conflicts with `DirsFirst`"},
+		{[]Option{WithSort(SortSize), WithSort(SortModTime)}, ErrConflict, "option `WithSort(mtime)` conflicts with `WithSort(size)`"},
+		{[]Option{WithSort(SortName), WithSort(SortSize)}, ErrConflict, "option `WithSort(size)` conflicts with `WithSort(name)`"},
+		{[]Option{WithSort(SortModTime), ChangeTime()}, ErrConflict, "option `ChangeTime` conflicts with `WithSort(mtime)`"},
+		{[]Option{WithColor(true), WithColor(false)}, ErrConflict, "option `WithColor(false)` conflicts with `WithColor(true)`"},
+	}
+
+	for _, tc := range tests {
+		_, err := NewConfig(tc.opts...)
+		if assert.Error(t, err, tc.msg) {
+			assert.True(t, errors.Is(err, tc.kind), tc.msg)
+			assert.Equal(t, tc.msg, err.Error())
+		}
+	}
+}
+
+func TestNewConfigListing(t *testing.T) {
+	config, err := NewConfig(WithFS(testFS()), WithPaths("resources/test-dir"), WithLevel(1), DirsOnly())
+	assert.NoError(t, err)
+	assert.Equal(t, "resources/test-dir\n│── empty\n└── hello\n\n2 directories", ListDirAndFiles(config))
+}
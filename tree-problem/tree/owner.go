@@ -0,0 +1,60 @@
+package tree
+
+import (
+	"os/user"
+	"strconv"
+	"sync"
+)
+
+// idNames caches the names of user or group ids: a listing looks up the
+// same few ids over and over, and each lookup may read /etc/passwd or ask
+// a directory service.
+type idNames struct {
+	mu     sync.Mutex
+	names  map[uint32]string
+	lookup func(id string) (string, error)
+}
+
+var (
+	userNames = &idNames{lookup: func(id string) (string, error) {
+		u, err := user.LookupId(id)
+		if err != nil {
+			return "", err
+		}
+		return u.Username, nil
+	}}
+	groupNames = &idNames{lookup: func(id string) (string, error) {
+		g, err := user.LookupGroupId(id)
+		if err != nil {
+			return "", err
+		}
+		return g.Name, nil
+	}}
+)
+
+// name returns the name of id, or id itself when it has none.
+func (c *idNames) name(id uint32) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if name, ok := c.names[id]; ok {
+		return name
+	}
+	name := strconv.FormatUint(uint64(id), 10)
+	if v, err := c.lookup(name); err == nil {
+		name = v
+	}
+	if c.names == nil {
+		c.names = make(map[uint32]string)
+	}
+	c.names[id] = name
+	return name
+}
+
+// ownerNames returns the user and group names of n, or "?" when the file
+// system does not tell who owns it.
+func ownerNames(n *Node) (userName, groupName string) {
+	if !n.ids {
+		return "?", "?"
+	}
+	return userNames.name(n.UID), groupNames.name(n.GID)
+}
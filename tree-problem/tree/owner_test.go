@@ -0,0 +1,42 @@
+package tree
+
+import (
+	"os/user"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOwnerUnknown(t *testing.T) {
+	fsys := fstest.MapFS{"a": dir(0), "a/f": file(1)}
+	tests := []test{
+		{cmd: "tree -u -g --inodes --device a", desc: "file systems without stat information",
+			want: "a\n└── [      ?    ? ?        ?       ] f\n\n0 directories, 1 file"},
+		{cmd: "tree -J -u -g --inodes --device a", desc: "unknown ids are left out of JSON",
+			want: "[\n  {\"type\":\"directory\",\"name\":\"a\",\"contents\":[\n" +
+				"    {\"type\":\"file\",\"name\":\"f\"}\n  ]}\n,\n" +
+				"  {\"type\":\"report\",\"directories\":0,\"files\":1}\n]"},
+	}
+	for _, tc := range tests {
+		config := mustParse(t, tc.cmd)
+		config.FS = fsys
+		assert.Equal(t, tc.want, ListDirAndFiles(config), tc.desc)
+	}
+}
+
+func TestIDNamesCache(t *testing.T) {
+	lookups := 0
+	names := &idNames{lookup: func(id string) (string, error) {
+		lookups++
+		if id == "0" {
+			return "root", nil
+		}
+		return "", user.UnknownUserIdError(7)
+	}}
+	assert.Equal(t, "root", names.name(0))
+	assert.Equal(t, "root", names.name(0))
+	assert.Equal(t, "7", names.name(7))
+	assert.Equal(t, "7", names.name(7))
+	assert.Equal(t, 2, lookups)
+}
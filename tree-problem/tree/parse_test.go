@@ -0,0 +1,125 @@
+package tree
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCommandErrors(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		kind error
+		msg  string
+	}{
+		{"ls -a", ErrUnknownCommand, "command not found: `ls`"},
+		{"tree -Z", ErrUnknownOption, "unknown option `-Z`"},
+		{"tree --nope=1", ErrUnknownOption, "unknown option `--nope`"},
+		{"tree -L", ErrMissingArgument, "option `-L` requires an argument"},
+		{"tree -P", ErrMissingArgument, "option `-P` requires an argument"},
+		{"tree -L 0", ErrInvalidValue, "invalid value `0` for option `-L`, want a number greater than 0"},
+		{"tree -L two", ErrInvalidValue, "invalid value `two` for option `-L`, want a number greater than 0"},
+		{"tree --jobs=-1", ErrInvalidValue, "invalid value `-1` for option `--jobs`, want a number greater than 0"},
+		{"tree --sort=age", ErrInvalidValue, "invalid value `age` for option `--sort`, want one of name, version, size, mtime, ctime, none"},
+		{"tree --hidden=some", ErrInvalidValue, "invalid value `some` for option `--hidden`, want none, all or config"},
+		{"tree -I [a", ErrInvalidValue, "invalid value `[a` for option `-I`, want a valid pattern"},
+		{"tree --help", ErrHelp, "help requested"},
+	}
+
+	for _, tc := range tests {
+		_, err := ParseCommand(tc.cmd)
+		if assert.Error(t, err, tc.cmd) {
+			assert.True(t, errors.Is(err, tc.kind), tc.cmd)
+			assert.Equal(t, tc.msg, err.Error(), tc.cmd)
+		}
+	}
+
+	var perr *ParseError
+	_, err := ParseCommand("tree -L 1 --sort age")
+	if assert.True(t, errors.As(err, &perr)) {
+		assert.Equal(t, ParseError{Err: ErrInvalidValue, Option: "--sort", Value: "age",
+			Want: "one of name, version, size, mtime, ctime, none"}, *perr)
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		args  []string
+		same  string   // the equivalent command line
+		paths []string // paths the command line cannot express
+	}{
+		{[]string{"-dfp"}, "tree -d -f -p", nil},
+		{[]string{"-L2", "-aI", "*.go"}, "tree -L 2 -a -I *.go", nil},
+		{[]string{"--level=2", "--sort", "size"}, "tree -L 2 --sort=size", nil},
+		{[]string{"--level", "2", "--hidden", "all"}, "tree -L 2 --hidden=all", nil},
+		{[]string{"-sh", "--", "-a", "--dirsfirst"}, "tree -s -h", []string{"-a", "--dirsfirst"}},
+		{[]string{"-"}, "tree", []string{"-"}},
+		{[]string{"--timeout=1m30s", "--max-entries", "100"}, "tree --timeout 90s --max-entries=100", nil},
+		{[]string{"--filelimit=500"}, "tree --filelimit 500", nil},
+		{[]string{"-pug", "--inodes", "--device"}, "tree -p -u -g --inodes --device", nil},
+		{[]string{"-xs", "--hardlinks"}, "tree -x -s --hardlinks", nil},
+	}
+
+	for _, tc := range tests {
+		got, err := ParseArgs(tc.args)
+		assert.NoError(t, err, tc.args)
+		want := mustParse(t, tc.same)
+		if tc.paths != nil {
+			want.paths = tc.paths
+		}
+		assert.Equal(t, want, got, tc.args)
+	}
+
+	errs := []struct {
+		args []string
+		msg  string
+	}{
+		{[]string{"-dZ"}, "unknown option `-Z`"},
+		{[]string{"-dL"}, "option `-L` requires an argument"},
+		{[]string{"--level"}, "option `--level` requires an argument"},
+		{[]string{"--dirsfirst=yes"}, "invalid value `yes` for option `--dirsfirst`, want no value"},
+		{[]string{"--si=1"}, "invalid value `1` for option `--si`, want no value"},
+		{[]string{"--timeout", "10"}, "invalid value `10` for option `--timeout`, want a positive duration such as 10s"},
+		{[]string{"--max-entries=0"}, "invalid value `0` for option `--max-entries`, want a number greater than 0"},
+	}
+	for _, tc := range errs {
+		_, err := ParseArgs(tc.args)
+		if assert.Error(t, err, tc.args) {
+			assert.Equal(t, tc.msg, err.Error(), tc.args)
+		}
+	}
+}
+
+func TestParseFormatFlags(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want Format
+	}{
+		{"tree", Text},
+		{"tree -J", JSON},
+		{"tree -J -X", XML},
+		{"tree -X -J", JSON},
+		{"tree -H /x -J", JSON},
+		{"tree -J -H /x", HTML},
+		{"tree -H /x -X", XML},
+		{"tree -X -H /x", HTML},
+		{"tree -J -X -H /x -J", JSON},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, mustParse(t, tc.cmd).format(), tc.cmd)
+	}
+}
+
+func TestParseArgsPaths(t *testing.T) {
+	fsys := fstest.MapFS{
+		"-odd dir":       dir(0),
+		"-odd dir/a b.c": file(1),
+	}
+	config, err := ParseArgs([]string{"-n", "--", "-odd dir"})
+	assert.NoError(t, err)
+	config.FS = fsys
+	assert.Equal(t, "-odd dir\n└── a b.c\n\n0 directories, 1 file", ListDirAndFiles(config))
+}
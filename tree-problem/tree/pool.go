@@ -0,0 +1,141 @@
+package tree
+
+import "sync"
+
+// pool reads directories ahead of the renderer with a fixed number of
+// workers. Output order is unaffected: the renderer still visits nodes
+// depth first and, on reaching a directory, either reads it itself when no
+// worker has claimed it yet or waits for the worker that has.
+//
+// Workers stop taking jobs while limit directories they read are still
+// waiting to be loaded, so the walk does not run unboundedly ahead of the
+// output.
+type pool struct {
+	wk     *walker
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queue  []job
+	ahead  map[*Node]bool // read by a worker, not loaded by anyone yet
+	limit  int
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// readAhead is how many directories each worker may read ahead of the
+// renderer.
+const readAhead = 32
+
+type job struct {
+	n     *Node
+	depth int
+}
+
+func newPool(wk *walker, workers int) *pool {
+	p := &pool{wk: wk, ahead: make(map[*Node]bool), limit: workers * readAhead}
+	p.cond = sync.NewCond(&p.mu)
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+func (p *pool) work() {
+	defer p.wg.Done()
+	for {
+		p.mu.Lock()
+		for (len(p.queue) == 0 || len(p.ahead) >= p.limit) && !p.closed {
+			p.cond.Wait()
+		}
+		if p.closed {
+			p.mu.Unlock()
+			return
+		}
+		j := p.queue[0]
+		p.queue = p.queue[1:]
+		claimed := p.claim(j.n)
+		p.mu.Unlock()
+
+		if claimed {
+			p.read(j.n, j.depth, true)
+		}
+	}
+}
+
+// load reads the children of n, found at depth, or waits until the worker
+// reading them is done.
+func (p *pool) load(n *Node, depth int) {
+	p.mu.Lock()
+	claimed := p.claim(n)
+	p.mu.Unlock()
+
+	if !claimed {
+		<-n.done
+		p.mu.Lock()
+		if p.ahead[n] {
+			delete(p.ahead, n)
+			p.cond.Broadcast()
+		}
+		p.mu.Unlock()
+		return
+	}
+	p.read(n, depth, false)
+}
+
+// enqueue hands the directories among nodes, found at depth, to the
+// workers.
+func (p *pool) enqueue(nodes []*Node, depth int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.push(nodes, depth)
+}
+
+// push queues the directories among nodes not claimed yet. p.mu must be
+// held.
+func (p *pool) push(nodes []*Node, depth int) {
+	if !p.wk.reads(depth) {
+		return
+	}
+	for _, n := range nodes {
+		if n.HasContents() && n.Err == nil && n.done == nil {
+			p.queue = append(p.queue, job{n, depth})
+		}
+	}
+	p.cond.Broadcast()
+}
+
+// claim marks n as being read and reports whether the caller is the one to
+// read it. p.mu must be held.
+func (p *pool) claim(n *Node) bool {
+	if n.done != nil {
+		return false
+	}
+	n.done = make(chan struct{})
+	return true
+}
+
+// read reads the children of n; ahead tells a worker read it without
+// anyone waiting on it.
+func (p *pool) read(n *Node, depth int, ahead bool) {
+	p.wk.read(n, depth)
+	children := n.Children // the renderer may drop them once done is closed
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if ahead && !p.closed {
+		p.ahead[n] = true
+	}
+	close(n.done)
+	p.push(children, depth+1)
+}
+
+// close stops the workers once their current reads are done; directories
+// still queued are dropped.
+func (p *pool) close() {
+	p.mu.Lock()
+	p.closed = true
+	p.queue = nil
+	p.cond.Broadcast()
+	p.mu.Unlock()
+	p.wg.Wait()
+}
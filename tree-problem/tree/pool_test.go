@@ -0,0 +1,123 @@
+package tree
+
+import (
+	"fmt"
+	"io/fs"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobsMatchSequentialWalk(t *testing.T) {
+	fsys := testFS()
+	fsys["resources/level-test-dir/in/zip.zip"] = &fstest.MapFile{Data: zipData(t, "a/b/c.txt", "d.txt")}
+
+	cmds := []string{
+		"tree resources",
+		"tree -L 2 resources",
+		"tree -d -t resources",
+		"tree --prune -P *.txt resources",
+		"tree --du -h resources",
+		"tree --du -L 1 --prune resources",
+		"tree --archives -J resources",
+		"tree -r -X resources/level-test-dir resources/test-dir",
+	}
+
+	for _, cmd := range cmds {
+		config := mustParse(t, cmd)
+		config.FS = fsys
+		want := ListDirAndFiles(config)
+		for _, jobs := range []string{" --jobs 2", " --jobs=8"} {
+			config := mustParse(t, cmd+jobs)
+			config.FS = fsys
+			assert.Equal(t, want, ListDirAndFiles(config), cmd+jobs)
+		}
+	}
+}
+
+func TestJobsBuild(t *testing.T) {
+	config := mustParse(t, "tree --jobs 4")
+	config.FS = testFS()
+	root := Build(config, "resources/test-dir")
+	assert.Len(t, root.Children, 2)
+	assert.Equal(t, "hello", root.Children[1].Name)
+	assert.Len(t, root.Children[1].Children, 3)
+}
+
+// countFS counts directory reads and how many of them overlap.
+type countFS struct {
+	fstest.MapFS
+	delay             time.Duration
+	reads, busy, most *int32
+}
+
+func newCountFS(fsys fstest.MapFS, delay time.Duration) countFS {
+	return countFS{MapFS: fsys, delay: delay, reads: new(int32), busy: new(int32), most: new(int32)}
+}
+
+func (c countFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	atomic.AddInt32(c.reads, 1)
+	busy := atomic.AddInt32(c.busy, 1)
+	defer atomic.AddInt32(c.busy, -1)
+	for {
+		most := atomic.LoadInt32(c.most)
+		if busy <= most || atomic.CompareAndSwapInt32(c.most, most, busy) {
+			break
+		}
+	}
+	time.Sleep(c.delay)
+	return c.MapFS.ReadDir(name)
+}
+
+func manyDirs(n int) fstest.MapFS {
+	fsys := fstest.MapFS{"root": dir(0)}
+	for i := 0; i < n; i++ {
+		fsys[fmt.Sprintf("root/d%03d/f", i)] = file(i)
+	}
+	return fsys
+}
+
+func TestJobsReadSubtreesInParallel(t *testing.T) {
+	for _, cmd := range []string{"tree --du --jobs 4 root", "tree --prune --jobs 4 root"} {
+		fsys := newCountFS(manyDirs(16), 5*time.Millisecond)
+		config := mustParse(t, cmd)
+		config.FS = fsys
+		assert.NoError(t, RenderTree(discardRenderer{}, config), cmd)
+		assert.Greater(t, atomic.LoadInt32(fsys.most), int32(1), cmd)
+	}
+}
+
+// stallRenderer holds up the walk at its first entry below the root.
+type stallRenderer struct {
+	discardRenderer
+	stall func()
+}
+
+func (s *stallRenderer) Entry(n *Node, pos Position) error {
+	if pos.Depth == 1 && s.stall != nil {
+		s.stall()
+		s.stall = nil
+	}
+	return nil
+}
+
+func TestJobsReadAhead(t *testing.T) {
+	const dirs, jobs = 400, 2
+	fsys := newCountFS(manyDirs(dirs), 0)
+	config := mustParse(t, fmt.Sprintf("tree --jobs %d root", jobs))
+	config.FS = fsys
+
+	var stalled int32
+	r := &stallRenderer{stall: func() {
+		time.Sleep(50 * time.Millisecond)
+		stalled = atomic.LoadInt32(fsys.reads)
+	}}
+	assert.NoError(t, RenderTree(r, config))
+	// the root, the read-ahead and the directories being read as the limit
+	// was reached
+	assert.LessOrEqual(t, stalled, int32(1+jobs*readAhead+jobs))
+	assert.Equal(t, int32(1+dirs), atomic.LoadInt32(fsys.reads))
+}
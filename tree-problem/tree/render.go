@@ -0,0 +1,326 @@
+package tree
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+)
+
+// Renderer formats the nodes of a walk. Entry is called for every node in
+// walk order, directories before their children; EndDir is called once a
+// directory's children have been rendered. Roots are passed at depth 0.
+type Renderer interface {
+	Begin() error
+	Entry(n *Node, pos Position) error
+	EndDir(n *Node, pos Position) error
+	End(r Report) error
+}
+
+// Position locates a node in the tree being rendered.
+type Position struct {
+	Depth int
+	Last  bool // last entry of its directory
+	// Parents holds, from depth 1 down, whether each ancestor of the node
+	// was the last entry of its own directory.
+	Parents []bool
+}
+
+func (p Position) child(last bool) Position {
+	c := Position{Depth: p.Depth + 1, Last: last}
+	if p.Depth > 0 {
+		c.Parents = append(p.Parents[:len(p.Parents):len(p.Parents)], p.Last)
+	}
+	return c
+}
+
+type textRenderer struct {
+	tw     *treeWriter
+	config TreeConfig
+	colors *lsColors // nil when names are not colored
+}
+
+func NewTextRenderer(w io.Writer, config TreeConfig) Renderer {
+	t := &textRenderer{tw: &treeWriter{w: w}, config: config}
+	if config.colorize() {
+		t.colors = newLSColors()
+	}
+	return t
+}
+
+func (t *textRenderer) Begin() error {
+	return t.tw.err
+}
+
+func (t *textRenderer) Entry(n *Node, pos Position) error {
+	if pos.Depth == 0 {
+		t.tw.print(t.colors.paint(n, n.Path), errorSuffix(n), NewLine)
+		return t.tw.err
+	}
+	bp := getBeforePipeVal(pos, t.config)        // before pipe
+	pipe := getPipeVal(pos.Last, t.config)       // pipe (│── or └──)
+	ap := getAfterPipeVal(n, t.config, t.colors) // after pipe
+	t.tw.print(bp, pipe, ap, NewLine)            //line structure in tree
+	return t.tw.err
+}
+
+func (t *textRenderer) EndDir(n *Node, pos Position) error {
+	return t.tw.err
+}
+
+func (t *textRenderer) End(r Report) error {
+	if lines := reportLines(r, t.config); len(lines) > 0 {
+		t.tw.print(NewLine, strings.Join(lines, NewLine), NewLine)
+	}
+	return t.tw.err
+}
+
+// reportLines returns the closing lines of the text and HTML output: the
+// counts of each root when there are several, their total, why the listing
+// stopped early and the stats.
+func reportLines(r Report, config TreeConfig) []string {
+	var lines []string
+	if !config.noReport {
+		for _, rr := range r.Roots {
+			lines = append(lines, rr.Path+": "+reportSummary(rr.report(), config))
+		}
+		lines = append(lines, reportSummary(r, config))
+	}
+	if r.Truncated != "" {
+		lines = append(lines, "listing truncated: "+r.Truncated)
+	}
+	if r.Stats != nil {
+		lines = append(lines, r.Stats.String())
+	}
+	return lines
+}
+
+// reportSummary returns the counts and total size of r on one line.
+func reportSummary(r Report, config TreeConfig) string {
+	dirStr := fmt.Sprintf("%v directories", r.Directories)
+	if r.Directories == 1 {
+		dirStr = fmt.Sprintf("%v directory", r.Directories)
+	}
+
+	fileStr := fmt.Sprintf("%v files", r.Files)
+	if r.Files == 1 {
+		fileStr = fmt.Sprintf("%v file", r.Files)
+	}
+
+	var s string
+	if config.showSize() {
+		s = formatTotalSize(r.Size, config) + " used in "
+	}
+	s += dirStr
+	if !config.reqOnlyDir {
+		s += ", " + fileStr
+	}
+	return s
+}
+
+func getBeforePipeVal(pos Position, config TreeConfig) string {
+	bp := ""
+	if config.noIndent {
+		return bp
+	}
+
+	for _, last := range pos.Parents {
+		if !last {
+			bp += BoxVer + Spaces3
+			continue
+		}
+		bp += Spaces4
+	}
+	return bp
+}
+
+func getPipeVal(isLastFile bool, config TreeConfig) string {
+	pipe := BoxVH //
+	if isLastFile {
+		pipe = BoxUpAndRig + BoxHor // └──
+	}
+	if config.noIndent {
+		pipe = ""
+	}
+	return pipe
+}
+
+func getAfterPipeVal(n *Node, config TreeConfig, colors *lsColors) string {
+	name := colors.paint(n, n.Name)
+	ap := Space + name     //after pipe
+	var relPath, fp string // fp: file permission, size and other metadata
+
+	if config.reqRelPath {
+		relPath = Space + colors.paint(n, n.Path)
+		ap = relPath
+	}
+
+	if fields := getMetaFields(n, config); len(fields) > 0 {
+		fp = Space + OpenBrkt + strings.Join(fields, Space) + CloseBrkt + Space
+		ap = fp + name
+	}
+
+	if config.reqRelPath && fp != "" {
+		ap = fp + relPath
+	}
+	return ap + linkSuffix(n) + hardLinkSuffix(n, config) + errorSuffix(n)
+}
+
+// linkSuffix returns what follows the name of a symbolic link: its target
+// and a note when it is broken or was not followed.
+func linkSuffix(n *Node) string {
+	if n.Type != Link {
+		return ""
+	}
+	var s string
+	if n.Target != "" {
+		s = " -> " + n.Target
+	}
+	switch {
+	case n.Broken:
+		s += "  [broken link]"
+	case n.Recursive:
+		s += "  [recursive, not followed]"
+	}
+	return s
+}
+
+// hardLinkSuffix returns the note of a file with several hard links: the
+// path it was listed under first, or its number of links.
+func hardLinkSuffix(n *Node, config TreeConfig) string {
+	switch {
+	case !n.hardLinked(&config):
+		return ""
+	case n.HardLink != "":
+		return " [hard link to " + n.HardLink + "]"
+	}
+	return fmt.Sprintf(" [%d links]", n.Links)
+}
+
+// errorNote returns why the contents of n are not listed, or "" when they
+// are or it has none.
+func errorNote(n *Node) string {
+	switch {
+	case n.OverLimit > 0:
+		return fmt.Sprintf("%d entries exceeds filelimit, not opening dir", n.OverLimit)
+	case n.Err == nil:
+		return ""
+	case n.Type == Archive:
+		return "error opening archive"
+	case n.HasContents():
+		return "error opening dir"
+	}
+	return "error reading file"
+}
+
+// errorSuffix returns the note GNU tree prints after entries it did not open.
+func errorSuffix(n *Node) string {
+	if note := errorNote(n); note != "" {
+		return " [" + note + "]"
+	}
+	return ""
+}
+
+// getMetaFields returns the metadata shown in brackets before a name, in
+// the order GNU tree prints them.
+func getMetaFields(n *Node, config TreeConfig) []string {
+	var fields []string
+	if config.reqInode {
+		fields = append(fields, formatID(n, "%7d", n.Inode))
+	}
+	if config.reqDevice {
+		fields = append(fields, formatID(n, "%4d", n.Device))
+	}
+	if config.reqFilePermsn {
+		fields = append(fields, getPermsnMode(n, false))
+	}
+	if config.reqUser || config.reqGroup {
+		userName, groupName := ownerNames(n)
+		if config.reqUser {
+			fields = append(fields, fmt.Sprintf("%-8s", userName))
+		}
+		if config.reqGroup {
+			fields = append(fields, fmt.Sprintf("%-8s", groupName))
+		}
+	}
+	if config.showSize() {
+		fields = append(fields, formatSize(n.Size, config))
+	}
+	if config.showTime() {
+		fields = append(fields, formatTime(nodeTime(n, config), config))
+	}
+	return fields
+}
+
+// formatID formats the inode or device number id of n, or "?" when the file
+// system has none, padded like GNU tree.
+func formatID(n *Node, format string, id uint64) string {
+	if !n.ids {
+		return fmt.Sprintf(strings.Replace(format, "d", "s", 1), "?")
+	}
+	return fmt.Sprintf(format, id)
+}
+
+// getPermsnMode returns the permissions of n as ls prints them, or in octal
+// including the setuid, setgid and sticky bits.
+func getPermsnMode(n *Node, inOctal bool) string {
+	m := n.Mode
+	bits := uint32(m.Perm())
+	if m&fs.ModeSetuid != 0 {
+		bits |= 04000
+	}
+	if m&fs.ModeSetgid != 0 {
+		bits |= 02000
+	}
+	if m&fs.ModeSticky != 0 {
+		bits |= 01000
+	}
+	if inOctal {
+		return fmt.Sprintf("%04o", bits)
+	}
+
+	b := []byte("-rwxrwxrwx")
+	switch {
+	case m.IsDir():
+		b[0] = 'd'
+	case m&fs.ModeSymlink != 0:
+		b[0] = 'l'
+	case m&fs.ModeNamedPipe != 0:
+		b[0] = 'p'
+	case m&fs.ModeSocket != 0:
+		b[0] = 's'
+	case m&fs.ModeCharDevice != 0:
+		b[0] = 'c'
+	case m&fs.ModeDevice != 0:
+		b[0] = 'b'
+	}
+	for i := 0; i < 9; i++ {
+		if bits&(1<<uint(8-i)) == 0 {
+			b[i+1] = '-'
+		}
+	}
+	special := []struct {
+		bit       uint32
+		at        int
+		set, bare byte // with and without the execute bit
+	}{{04000, 3, 's', 'S'}, {02000, 6, 's', 'S'}, {01000, 9, 't', 'T'}}
+	for _, sp := range special {
+		if bits&sp.bit == 0 {
+			continue
+		}
+		if b[sp.at] == 'x' {
+			b[sp.at] = sp.set
+		} else {
+			b[sp.at] = sp.bare
+		}
+	}
+	return string(b)
+}
+
+// discardRenderer is used when only the node model is wanted.
+type discardRenderer struct{}
+
+func (discardRenderer) Begin() error                       { return nil }
+func (discardRenderer) Entry(n *Node, pos Position) error  { return nil }
+func (discardRenderer) EndDir(n *Node, pos Position) error { return nil }
+func (discardRenderer) End(r Report) error                 { return nil }
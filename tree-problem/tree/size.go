@@ -0,0 +1,56 @@
+package tree
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	binaryUnits  = "BKMGTPEZY"
+	decimalUnits = "BkMGTPEZY"
+)
+
+// showSize reports whether sizes are printed; -h, --si and --du imply -s.
+func (c TreeConfig) showSize() bool {
+	return c.reqSize || c.humanSize || c.siUnits || c.du
+}
+
+// formatSize formats the size column: bytes padded like GNU tree, or four
+// characters wide with -h (powers of 1024) and --si (powers of 1000).
+func formatSize(size int64, config TreeConfig) string {
+	switch {
+	case config.siUnits:
+		return humanSize(size, 1000, decimalUnits)
+	case config.humanSize:
+		return humanSize(size, 1024, binaryUnits)
+	}
+	return fmt.Sprintf("%11d", size)
+}
+
+func formatTotalSize(size int64, config TreeConfig) string {
+	if config.siUnits || config.humanSize {
+		return strings.TrimSpace(formatSize(size, config))
+	}
+	return strconv.FormatInt(size, 10) + " bytes"
+}
+
+func humanSize(size, unit int64, units string) string {
+	idx := 0
+	if size >= unit {
+		idx = 1
+	}
+	for size >= unit*unit && idx < len(units)-1 {
+		idx++
+		size /= unit
+	}
+	if idx == 0 {
+		return fmt.Sprintf("%4d", size)
+	}
+
+	v := float64(size) / float64(unit)
+	if size/unit >= 10 {
+		return fmt.Sprintf("%3.0f%c", v, units[idx])
+	}
+	return fmt.Sprintf("%3.1f%c", v, units[idx])
+}
@@ -0,0 +1,119 @@
+package tree
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSizes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"out":            dir(0),
+		"out/app":        &fstest.MapFile{Data: make([]byte, 3000), Mode: 0644},
+		"out/lib":        dir(1),
+		"out/lib/a.so":   &fstest.MapFile{Data: make([]byte, 1500), Mode: 0644},
+		"out/lib/x":      dir(2),
+		"out/lib/x/b.so": &fstest.MapFile{Data: make([]byte, 600), Mode: 0644},
+	}
+
+	tests := []test{
+		{cmd: "tree -s out", desc: "byte sizes",
+			want: "out\n" +
+				"│── [       3000] app\n" +
+				"└── [          0] lib\n" +
+				"    │── [       1500] a.so\n" +
+				"    └── [          0] x\n" +
+				"        └── [        600] b.so\n\n" +
+				"5100 bytes used in 2 directories, 3 files"},
+		{cmd: "tree -h -p -L 1 out", desc: "human readable sizes after permissions",
+			want: "out\n" +
+				"│── [-rw-r--r-- 2.9K] app\n" +
+				"└── [drwxr-xr-x    0] lib\n\n" +
+				"2.9K used in 1 directory, 1 file"},
+		{cmd: "tree --du --si -L 1 out", desc: "cumulative sizes include levels not shown",
+			want: "out\n" +
+				"│── [3.0k] app\n" +
+				"└── [2.1k] lib\n\n" +
+				"5.1k used in 1 directory, 1 file"},
+		{cmd: "tree --du -X -L 1 out", desc: "cumulative sizes in XML",
+			want: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<tree>\n" +
+				"  <directory name=\"out\">\n" +
+				"    <file name=\"app\" size=\"3000\"></file>\n" +
+				"    <directory name=\"lib\" size=\"2100\">\n" +
+				"    </directory>\n" +
+				"  </directory>\n" +
+				"  <report>\n    <size>5100</size>\n    <directories>1</directories>\n    <files>1</files>\n  </report>\n</tree>"},
+		{cmd: "tree --du -J out/lib", desc: "cumulative sizes in JSON",
+			want: "[\n  {\"type\":\"directory\",\"name\":\"out/lib\",\"contents\":[\n" +
+				"    {\"type\":\"file\",\"name\":\"a.so\",\"size\":1500},\n" +
+				"    {\"type\":\"directory\",\"name\":\"x\",\"size\":600,\"contents\":[\n" +
+				"      {\"type\":\"file\",\"name\":\"b.so\",\"size\":600}\n" +
+				"    ]}\n  ]}\n,\n" +
+				"  {\"type\":\"report\",\"size\":2100,\"directories\":1,\"files\":2}\n]"},
+	}
+
+	for _, tc := range tests {
+		config := mustParse(t, tc.cmd)
+		config.FS = fsys
+		assert.Equal(t, tc.want, ListDirAndFiles(config), tc.desc)
+	}
+}
+
+func TestHumanSize(t *testing.T) {
+	assert.Equal(t, "1023", humanSize(1023, 1024, binaryUnits))
+	assert.Equal(t, "1.0K", humanSize(1024, 1024, binaryUnits))
+	assert.Equal(t, " 10K", humanSize(10*1024, 1024, binaryUnits))
+	assert.Equal(t, "1.5M", humanSize(1536*1024, 1024, binaryUnits))
+	assert.Equal(t, "1.0k", humanSize(1000, 1000, decimalUnits))
+	assert.Equal(t, "2.5G", humanSize(2500000000, 1000, decimalUnits))
+}
+
+// depthRenderer records, on rendering the root, whether nodes below depth
+// are still held.
+type depthRenderer struct {
+	discardRenderer
+	depth int
+	held  bool
+}
+
+func (d *depthRenderer) Entry(n *Node, pos Position) error {
+	if pos.Depth == 0 {
+		d.held = holds(n, 0, d.depth)
+	}
+	return nil
+}
+
+func holds(n *Node, depth, limit int) bool {
+	if depth > limit {
+		return true
+	}
+	for _, c := range n.Children {
+		if holds(c, depth+1, limit) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDiskUsageDropsUnlisted(t *testing.T) {
+	fsys := fstest.MapFS{
+		"r":         dir(0),
+		"r/a":       dir(1),
+		"r/a/b":     dir(2),
+		"r/a/b/c":   dir(3),
+		"r/a/b/c/f": &fstest.MapFile{Data: make([]byte, 10), Mode: 0644},
+	}
+	for _, cmd := range []string{"tree --du -L 1 r", "tree --du -L 2 --jobs 4 r"} {
+		config := mustParse(t, cmd)
+		config.FS = fsys
+		r := &depthRenderer{depth: config.level}
+		assert.NoError(t, RenderTree(r, config), cmd)
+		assert.False(t, r.held, "%v: subtrees beyond the level are kept until the root is rendered", cmd)
+
+		var sb strings.Builder
+		assert.NoError(t, WriteTree(&sb, config), cmd)
+		assert.Contains(t, sb.String(), "10 bytes used", cmd)
+	}
+}
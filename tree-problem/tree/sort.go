@@ -0,0 +1,174 @@
+package tree
+
+import (
+	"sort"
+	"strings"
+)
+
+// SortKey selects the order entries of a directory are listed in.
+type SortKey int
+
+const (
+	SortName       SortKey = iota // by name, the default
+	SortVersion                   // by name, with runs of digits compared as numbers (-v)
+	SortSize                      // largest first
+	SortModTime                   // oldest modification first (-t)
+	SortChangeTime                // oldest status change first (-c)
+	SortNone                      // in directory order (-U)
+)
+
+var sortKeyNames = []string{"name", "version", "size", "mtime", "ctime", "none"}
+
+func ParseSortKey(s string) (SortKey, bool) {
+	for i, name := range sortKeyNames {
+		if s == name {
+			return SortKey(i), true
+		}
+	}
+	return SortName, false
+}
+
+func (k SortKey) String() string {
+	if k < 0 || int(k) >= len(sortKeyNames) {
+		return "unknown"
+	}
+	return sortKeyNames[k]
+}
+
+// sortNodes orders nodes by the configured key, breaking ties by name, and
+// then groups directories before or after files. -r reverses the key order
+// but not the grouping.
+func sortNodes(nodes []*Node, config TreeConfig) {
+	if config.sortKey != SortNone {
+		less := nodeLess(config)
+		sort.SliceStable(nodes, func(i, j int) bool {
+			if config.reverse {
+				return less(nodes[j], nodes[i])
+			}
+			return less(nodes[i], nodes[j])
+		})
+	}
+
+	if config.dirsFirst || config.filesFirst {
+		sort.SliceStable(nodes, func(i, j int) bool {
+			a, b := nodes[i].groupsAsDir(), nodes[j].groupsAsDir()
+			return a != b && a == config.dirsFirst
+		})
+	}
+}
+
+// groupsAsDir reports whether n goes with the directories for --dirsfirst
+// and --filesfirst, as links to directories do.
+func (n *Node) groupsAsDir() bool {
+	return n.HasContents() || n.linkDir
+}
+
+func nodeLess(config TreeConfig) func(a, b *Node) bool {
+	byName := func(a, b *Node) bool {
+		return compareNames(a.Name, b.Name, config.sortIgnoreCase) < 0
+	}
+
+	switch config.sortKey {
+	case SortVersion:
+		return func(a, b *Node) bool {
+			if c := compareVersions(a.Name, b.Name, config.sortIgnoreCase); c != 0 {
+				return c < 0
+			}
+			return a.Name < b.Name
+		}
+	case SortSize:
+		return func(a, b *Node) bool {
+			if a.Size != b.Size {
+				return a.Size > b.Size
+			}
+			return byName(a, b)
+		}
+	case SortModTime:
+		return func(a, b *Node) bool {
+			if !a.ModTime.Equal(b.ModTime) {
+				return a.ModTime.Before(b.ModTime)
+			}
+			return byName(a, b)
+		}
+	case SortChangeTime:
+		return func(a, b *Node) bool {
+			if !a.ChangeTime.Equal(b.ChangeTime) {
+				return a.ChangeTime.Before(b.ChangeTime)
+			}
+			return byName(a, b)
+		}
+	}
+	return byName
+}
+
+// compareNames compares names byte-wise, or case-insensitively with
+// --sort-ignore-case falling back to byte order for names differing only in case.
+func compareNames(a, b string, ignoreCase bool) int {
+	if ignoreCase {
+		if c := strings.Compare(strings.ToLower(a), strings.ToLower(b)); c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+// compareVersions compares names the way natural sort does: runs of digits
+// are compared by numeric value, everything else byte-wise.
+func compareVersions(a, b string, ignoreCase bool) int {
+	if ignoreCase {
+		a, b = strings.ToLower(a), strings.ToLower(b)
+	}
+
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			na, nb := digitPrefix(a), digitPrefix(b)
+			if c := compareNumbers(na, nb); c != 0 {
+				return c
+			}
+			a, b = a[len(na):], b[len(nb):]
+			continue
+		}
+
+		if a[0] != b[0] {
+			if a[0] < b[0] {
+				return -1
+			}
+			return 1
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) - len(b)
+}
+
+// compareNumbers compares two digit strings of any length by value.
+func compareNumbers(a, b string) int {
+	ta, tb := strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(ta) != len(tb) {
+		return len(ta) - len(tb)
+	}
+	if c := strings.Compare(ta, tb); c != 0 {
+		return c
+	}
+	// equal values: fewer leading zeros first
+	return len(a) - len(b)
+}
+
+func digitPrefix(s string) string {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i]
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func SortByModTime(nodes []*Node) {
+	sortNodes(nodes, TreeConfig{sortKey: SortModTime})
+}
+
+func SortByChangeTime(nodes []*Node) {
+	sortNodes(nodes, TreeConfig{sortKey: SortChangeTime})
+}
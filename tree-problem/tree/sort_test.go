@@ -0,0 +1,90 @@
+package tree
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSorting(t *testing.T) {
+	fsys := fstest.MapFS{
+		"rel":              dir(0),
+		"rel/v10.txt":      &fstest.MapFile{Data: make([]byte, 10), Mode: 0644, ModTime: epoch},
+		"rel/v2.txt":       &fstest.MapFile{Data: make([]byte, 30), Mode: 0644, ModTime: epoch.Add(2)},
+		"rel/V9.txt":       &fstest.MapFile{Data: make([]byte, 20), Mode: 0644, ModTime: epoch.Add(1)},
+		"rel/build":        dir(3),
+		"rel/build/b2.bin": file(4),
+	}
+
+	tests := []test{
+		{cmd: "tree -L 1 rel", desc: "byte-wise name order by default",
+			want: "rel\n" +
+				"│── V9.txt\n" +
+				"│── build\n" +
+				"│── v10.txt\n" +
+				"└── v2.txt\n\n" +
+				"1 directory, 3 files"},
+		{cmd: "tree -L 1 --sort=name --sort-ignore-case rel", desc: "case-insensitive name order",
+			want: "rel\n" +
+				"│── build\n" +
+				"│── v10.txt\n" +
+				"│── v2.txt\n" +
+				"└── V9.txt\n\n" +
+				"1 directory, 3 files"},
+		{cmd: "tree -L 1 -v --sort-ignore-case rel", desc: "version order compares numbers",
+			want: "rel\n" +
+				"│── build\n" +
+				"│── v2.txt\n" +
+				"│── V9.txt\n" +
+				"└── v10.txt\n\n" +
+				"1 directory, 3 files"},
+		{cmd: "tree -L 1 --ignore-case -P v* rel", desc: "--ignore-case leaves the order alone",
+			want: "rel\n" +
+				"│── V9.txt\n" +
+				"│── build\n" +
+				"│── v10.txt\n" +
+				"└── v2.txt\n\n" +
+				"1 directory, 3 files"},
+		{cmd: "tree -L 1 --sort size -r --dirsfirst rel", desc: "reverse size order with directories first",
+			want: "rel\n" +
+				"│── build\n" +
+				"│── v10.txt\n" +
+				"│── V9.txt\n" +
+				"└── v2.txt\n\n" +
+				"1 directory, 3 files"},
+		{cmd: "tree --sort=mtime --filesfirst rel", desc: "modification time order with files first",
+			want: "rel\n" +
+				"│── v10.txt\n" +
+				"│── V9.txt\n" +
+				"│── v2.txt\n" +
+				"└── build\n" +
+				"    └── b2.bin\n\n" +
+				"1 directory, 4 files"},
+		{cmd: "tree -X -v -r -L 1 rel", desc: "sorting applies to XML",
+			want: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<tree>\n" +
+				"  <directory name=\"rel\">\n" +
+				"    <file name=\"v10.txt\"></file>\n" +
+				"    <file name=\"v2.txt\"></file>\n" +
+				"    <directory name=\"build\">\n" +
+				"    </directory>\n" +
+				"    <file name=\"V9.txt\"></file>\n" +
+				"  </directory>\n" +
+				"  <report>\n    <directories>1</directories>\n    <files>3</files>\n  </report>\n</tree>"},
+	}
+
+	for _, tc := range tests {
+		config := mustParse(t, tc.cmd)
+		config.FS = fsys
+		assert.Equal(t, tc.want, ListDirAndFiles(config), tc.desc)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	assert.Less(t, compareVersions("file2", "file10", false), 0)
+	assert.Less(t, compareVersions("1.9.0", "1.10.0", false), 0)
+	assert.Less(t, compareVersions("a01", "a001", false), 0)
+	assert.Greater(t, compareVersions("b", "a100", false), 0)
+	assert.Equal(t, 0, compareVersions("App-2", "app-2", true))
+	assert.Less(t, compareVersions("app", "app1", false), 0)
+}
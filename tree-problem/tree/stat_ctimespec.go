@@ -0,0 +1,18 @@
+//go:build darwin || freebsd || netbsd
+
+package tree
+
+import (
+	"io/fs"
+	"syscall"
+	"time"
+)
+
+// changeTime returns the status change time of fi, or its modification
+// time when the file system does not provide one.
+func changeTime(fi fs.FileInfo) time.Time {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return time.Unix(st.Ctimespec.Unix())
+	}
+	return fi.ModTime()
+}
@@ -0,0 +1,14 @@
+//go:build !(linux || openbsd || dragonfly || solaris || darwin || freebsd || netbsd)
+
+package tree
+
+import (
+	"io/fs"
+	"time"
+)
+
+// changeTime falls back to the modification time where the platform has
+// no status change time.
+func changeTime(fi fs.FileInfo) time.Time {
+	return fi.ModTime()
+}
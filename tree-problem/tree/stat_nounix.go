@@ -0,0 +1,20 @@
+//go:build !unix
+
+package tree
+
+import "io/fs"
+
+// fileID reports no device and inode numbers where the platform has none.
+func fileID(fi fs.FileInfo) (dev, ino uint64, ok bool) {
+	return 0, 0, false
+}
+
+// fileOwner reports no user and group ids where the platform has none.
+func fileOwner(fi fs.FileInfo) (uid, gid uint32, ok bool) {
+	return 0, 0, false
+}
+
+// fileLinks reports no hard links where the platform has no count of them.
+func fileLinks(fi fs.FileInfo) uint64 {
+	return 0
+}
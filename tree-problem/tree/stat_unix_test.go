@@ -0,0 +1,139 @@
+//go:build unix
+
+package tree
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOwnerAndIDs(t *testing.T) {
+	root := t.TempDir()
+	name := filepath.Join(root, "f")
+	assert.NoError(t, os.WriteFile(name, nil, 0640))
+	fi, err := os.Stat(name)
+	assert.NoError(t, err)
+	st := fi.Sys().(*syscall.Stat_t)
+
+	userName := strconv.FormatUint(uint64(st.Uid), 10)
+	if u, err := user.LookupId(userName); err == nil {
+		userName = u.Username
+	}
+	groupName := strconv.FormatUint(uint64(st.Gid), 10)
+	if g, err := user.LookupGroupId(groupName); err == nil {
+		groupName = g.Name
+	}
+
+	got := ListDirAndFiles(mustParse(t, "tree -p -u -g --inodes --device "+root))
+	want := fmt.Sprintf("%v\n└── [%7d %4d -rw-r----- %-8s %-8s] f\n\n0 directories, 1 file",
+		root, st.Ino, st.Dev, userName, groupName)
+	assert.Equal(t, want, got)
+
+	got = ListDirAndFiles(mustParse(t, "tree -J -u -g --inodes --device "+root))
+	assert.Contains(t, got, fmt.Sprintf("{\"type\":\"file\",\"name\":\"f\",\"inode\":%d,\"dev\":%d,\"user\":%q,\"group\":%q}",
+		st.Ino, st.Dev, userName, groupName))
+
+	got = ListDirAndFiles(mustParse(t, "tree -X -u --inodes "+root))
+	assert.Contains(t, got, fmt.Sprintf("<file name=\"f\" inode=\"%d\" user=\"%v\"></file>", st.Ino, userName))
+}
+
+// statFile returns a file of 100 bytes with the given stat information.
+func statFile(mode fs.FileMode, dev, ino, nlink int) *fstest.MapFile {
+	// the types of the Stat_t fields differ between platforms
+	st := &syscall.Stat_t{}
+	for ; dev > 0; dev-- {
+		st.Dev++
+	}
+	for ; ino > 0; ino-- {
+		st.Ino++
+	}
+	for ; nlink > 0; nlink-- {
+		st.Nlink++
+	}
+	return &fstest.MapFile{Mode: mode, Data: make([]byte, 100), Sys: st}
+}
+
+func TestOneFileSystem(t *testing.T) {
+	fsys := fstest.MapFS{
+		"root":            statFile(fs.ModeDir|0755, 1, 1, 2),
+		"root/etc":        statFile(fs.ModeDir|0755, 1, 2, 2),
+		"root/etc/passwd": statFile(0644, 1, 3, 1),
+		"root/proc":       statFile(fs.ModeDir|0555, 2, 1, 2),
+		"root/proc/1":     statFile(fs.ModeDir|0555, 2, 2, 2),
+	}
+
+	tests := []test{
+		{cmd: "tree root", desc: "mount points are crossed by default",
+			want: "root\n│── etc\n│   └── passwd\n└── proc\n    └── 1\n\n3 directories, 1 file"},
+		{cmd: "tree -x root", desc: "-x lists mount points without opening them",
+			want: "root\n│── etc\n│   └── passwd\n└── proc\n\n2 directories, 1 file"},
+		{cmd: "tree -x --prune --du -s root", desc: "-x mount points are kept by --prune and not sized",
+			want: "root\n│── [        200] etc\n│   └── [        100] passwd\n└── [        100] proc\n\n400 bytes used in 2 directories, 1 file"},
+	}
+	for _, tc := range tests {
+		config := mustParse(t, tc.cmd)
+		config.FS = fsys
+		assert.Equal(t, tc.want, ListDirAndFiles(config), tc.desc)
+	}
+}
+
+func TestHardLinks(t *testing.T) {
+	fsys := fstest.MapFS{
+		"r":     statFile(fs.ModeDir|0755, 1, 1, 2),
+		"r/a":   statFile(fs.ModeDir|0755, 1, 2, 2),
+		"r/a/x": statFile(0644, 1, 3, 3),
+		"r/b":   statFile(fs.ModeDir|0755, 1, 4, 2),
+		"r/b/y": statFile(0644, 1, 3, 3),
+		"r/z":   statFile(0644, 1, 3, 3),
+		"r/w":   statFile(0644, 1, 5, 1),
+	}
+
+	tests := []test{
+		{cmd: "tree r", desc: "hard links are not looked for by default",
+			want: "r\n│── a\n│   └── x\n│── b\n│   └── y\n│── w\n└── z\n\n2 directories, 4 files"},
+		{cmd: "tree --hardlinks r", desc: "--hardlinks marks them and counts them once",
+			want: "r\n│── a\n│   └── x [3 links]\n│── b\n│   └── y [hard link to r/a/x]\n" +
+				"│── w\n└── z [hard link to r/a/x]\n\n2 directories, 2 files"},
+		{cmd: "tree -s r", desc: "so do sizes",
+			want: "r\n│── [        100] a\n│   └── [        100] x [3 links]\n│── [        100] b\n│   └── [        100] y [hard link to r/a/x]\n" +
+				"│── [        100] w\n└── [        100] z [hard link to r/a/x]\n\n400 bytes used in 2 directories, 2 files"},
+		{cmd: "tree --du -s r", desc: "--du counts each file once, where it is listed first",
+			want: "r\n│── [        200] a\n│   └── [        100] x [3 links]\n│── [        100] b\n│   └── [        100] y [hard link to r/a/x]\n" +
+				"│── [        100] w\n└── [        100] z [hard link to r/a/x]\n\n500 bytes used in 2 directories, 2 files"},
+		{cmd: "tree --du -s -r r", desc: "--du follows the sort order",
+			want: "r\n│── [        100] z [3 links]\n│── [        100] w\n│── [        100] b\n│   └── [        100] y [hard link to r/z]\n" +
+				"└── [        100] a\n    └── [        100] x [hard link to r/z]\n\n500 bytes used in 2 directories, 2 files"},
+		{cmd: "tree --du -s -L 1 -I z r", desc: "--du counts unlisted ones where the walk meets them first",
+			want: "r\n│── [        200] a\n│── [        100] b\n└── [        100] w\n\n500 bytes used in 2 directories, 1 file"},
+		{cmd: "tree --du -s -L 1 r", desc: "--du prefers listed files to those beyond the level",
+			want: "r\n│── [        100] a\n│── [        100] b\n│── [        100] w\n└── [        100] z [3 links]\n\n500 bytes used in 2 directories, 2 files"},
+		{cmd: "tree -J --hardlinks -L 1 r", desc: "JSON links and hardlink fields",
+			want: "[\n  {\"type\":\"directory\",\"name\":\"r\",\"contents\":[\n" +
+				"    {\"type\":\"directory\",\"name\":\"a\",\"contents\":[\n    ]},\n" +
+				"    {\"type\":\"directory\",\"name\":\"b\",\"contents\":[\n    ]},\n" +
+				"    {\"type\":\"file\",\"name\":\"w\"},\n" +
+				"    {\"type\":\"file\",\"name\":\"z\",\"links\":3}\n  ]}\n,\n" +
+				"  {\"type\":\"report\",\"directories\":2,\"files\":2}\n]"},
+		{cmd: "tree -X --hardlinks r/a r/b", desc: "XML links and hardlink attributes",
+			want: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<tree>\n" +
+				"  <directory name=\"r/a\">\n    <file name=\"x\" links=\"3\"></file>\n  </directory>\n" +
+				"  <directory name=\"r/b\">\n    <file name=\"y\" links=\"3\" hardlink=\"r/a/x\"></file>\n  </directory>\n" +
+				"  <report>\n    <directories>0</directories>\n    <files>1</files>\n" +
+				"    <root name=\"r/a\" directories=\"0\" files=\"1\"></root>\n" +
+				"    <root name=\"r/b\" directories=\"0\" files=\"0\"></root>\n  </report>\n</tree>"},
+	}
+	for _, tc := range tests {
+		config := mustParse(t, tc.cmd)
+		config.FS = fsys
+		assert.Equal(t, tc.want, ListDirAndFiles(config), tc.desc)
+	}
+}
@@ -0,0 +1,138 @@
+package tree
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	recentTimeFmt = "%b %e %H:%M"
+	oldTimeFmt    = "%b %e  %Y"
+)
+
+// showTime reports whether times are printed; --timefmt implies -D.
+func (c TreeConfig) showTime() bool {
+	return c.reqTime || c.timeFmt != ""
+}
+
+// nodeTime is the time -D prints and -t/-c sort by: the status change time
+// with -c, the modification time otherwise.
+func nodeTime(n *Node, config TreeConfig) time.Time {
+	if config.changeTime {
+		return n.ChangeTime
+	}
+	return n.ModTime
+}
+
+// formatTime formats t with --timefmt, or like ls(1) and GNU tree: the
+// year for times older than six months or in the future, the time of day
+// for recent ones.
+func formatTime(t time.Time, config TreeConfig) string {
+	if config.timeFmt != "" {
+		return strftime(t, config.timeFmt)
+	}
+
+	now := time.Now()
+	if t.After(now) || t.Before(now.AddDate(0, -6, 0)) {
+		return strftime(t, oldTimeFmt)
+	}
+	return strftime(t, recentTimeFmt)
+}
+
+// strftime formats t according to the C strftime conversion specifications.
+// Unknown specifications are copied to the output unchanged.
+func strftime(t time.Time, format string) string {
+	var sb strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i == len(format)-1 {
+			sb.WriteByte(format[i])
+			continue
+		}
+
+		i++
+		switch c := format[i]; c {
+		case 'a':
+			sb.WriteString(t.Format("Mon"))
+		case 'A':
+			sb.WriteString(t.Format("Monday"))
+		case 'b', 'h':
+			sb.WriteString(t.Format("Jan"))
+		case 'B':
+			sb.WriteString(t.Format("January"))
+		case 'c':
+			sb.WriteString(t.Format("Mon Jan _2 15:04:05 2006"))
+		case 'C':
+			fmt.Fprintf(&sb, "%02d", t.Year()/100)
+		case 'd':
+			fmt.Fprintf(&sb, "%02d", t.Day())
+		case 'D':
+			sb.WriteString(t.Format("01/02/06"))
+		case 'e':
+			fmt.Fprintf(&sb, "%2d", t.Day())
+		case 'F':
+			sb.WriteString(t.Format("2006-01-02"))
+		case 'H':
+			fmt.Fprintf(&sb, "%02d", t.Hour())
+		case 'I':
+			fmt.Fprintf(&sb, "%02d", hour12(t))
+		case 'j':
+			fmt.Fprintf(&sb, "%03d", t.YearDay())
+		case 'k':
+			fmt.Fprintf(&sb, "%2d", t.Hour())
+		case 'l':
+			fmt.Fprintf(&sb, "%2d", hour12(t))
+		case 'm':
+			fmt.Fprintf(&sb, "%02d", int(t.Month()))
+		case 'M':
+			fmt.Fprintf(&sb, "%02d", t.Minute())
+		case 'n':
+			sb.WriteString(NewLine)
+		case 'p':
+			sb.WriteString(t.Format("PM"))
+		case 'r':
+			sb.WriteString(t.Format("03:04:05 PM"))
+		case 'R':
+			sb.WriteString(t.Format("15:04"))
+		case 's':
+			sb.WriteString(strconv.FormatInt(t.Unix(), 10))
+		case 'S':
+			fmt.Fprintf(&sb, "%02d", t.Second())
+		case 't':
+			sb.WriteString("\t")
+		case 'T':
+			sb.WriteString(t.Format("15:04:05"))
+		case 'u':
+			wd := int(t.Weekday())
+			if wd == 0 {
+				wd = 7
+			}
+			sb.WriteString(strconv.Itoa(wd))
+		case 'w':
+			sb.WriteString(strconv.Itoa(int(t.Weekday())))
+		case 'y':
+			fmt.Fprintf(&sb, "%02d", t.Year()%100)
+		case 'Y':
+			sb.WriteString(strconv.Itoa(t.Year()))
+		case 'z':
+			sb.WriteString(t.Format("-0700"))
+		case 'Z':
+			sb.WriteString(t.Format("MST"))
+		case '%':
+			sb.WriteByte('%')
+		default:
+			sb.WriteByte('%')
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+func hour12(t time.Time) int {
+	h := t.Hour() % 12
+	if h == 0 {
+		h = 12
+	}
+	return h
+}
@@ -0,0 +1,56 @@
+package tree
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"logs":       dir(0),
+		"logs/a.log": file(90),
+		"logs/b.log": file(61),
+	}
+
+	tests := []test{
+		{cmd: "tree -D logs", desc: "old times show the year",
+			want: "logs\n" +
+				"│── [Dec  1  2022] a.log\n" +
+				"└── [Dec  1  2022] b.log\n\n" +
+				"0 directories, 2 files"},
+		{cmd: "tree -t --timefmt=%F_%H:%M logs", desc: "--timefmt implies -D",
+			want: "logs\n" +
+				"│── [2022-12-01_01:01] b.log\n" +
+				"└── [2022-12-01_01:30] a.log\n\n" +
+				"0 directories, 2 files"},
+		{cmd: "tree -p -s --timefmt %s logs", desc: "time follows permissions and size",
+			want: "logs\n" +
+				"│── [-rw-r--r--           0 1669858200] a.log\n" +
+				"└── [-rw-r--r--           0 1669856460] b.log\n\n" +
+				"0 bytes used in 0 directories, 2 files"},
+		{cmd: "tree -J -c --timefmt %R logs", desc: "times in JSON",
+			want: "[\n  {\"type\":\"directory\",\"name\":\"logs\",\"contents\":[\n" +
+				"    {\"type\":\"file\",\"name\":\"b.log\",\"time\":\"01:01\"},\n" +
+				"    {\"type\":\"file\",\"name\":\"a.log\",\"time\":\"01:30\"}\n" +
+				"  ]}\n,\n" +
+				"  {\"type\":\"report\",\"directories\":0,\"files\":2}\n]"},
+	}
+
+	for _, tc := range tests {
+		config := mustParse(t, tc.cmd)
+		config.FS = fsys
+		assert.Equal(t, tc.want, ListDirAndFiles(config), tc.desc)
+	}
+}
+
+func TestStrftime(t *testing.T) {
+	tm := time.Date(2023, time.March, 5, 14, 7, 9, 0, time.UTC)
+	assert.Equal(t, "Mar  5 14:07", strftime(tm, recentTimeFmt))
+	assert.Equal(t, "Mar  5  2023", strftime(tm, oldTimeFmt))
+	assert.Equal(t, "Sunday 05/03/23 02:07:09 PM 064 7", strftime(tm, "%A %d/%m/%y %r %j %u"))
+	assert.Equal(t, "100% %Q", strftime(tm, "100%% %Q"))
+	assert.Equal(t, "trailing %", strftime(tm, "trailing %"))
+}
@@ -1,24 +1,36 @@
 package tree
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
-	"log"
 	"os"
-	"regexp"
-	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
-type FileCount struct {
-	dirCnt, fileCnt int
-}
-
 type TreeConfig struct {
-	reqRelPath, reqOnlyDir, reqFilePermsn, sortByModTime, noIndent, reqXmlFormat, reqJsonFormat bool
-	level                                                                                       int
+	reqRelPath, reqOnlyDir, reqFilePermsn, noIndent, reqXmlFormat, reqJsonFormat, reqHtmlFormat bool
+	archives, ignoreCase, matchDirs, prune, gitignore                                           bool
+	reqSize, humanSize, siUnits, du, reqTime, changeTime, followLinks, stats, noReport          bool
+	reqUser, reqGroup, reqInode, reqDevice, oneFS, hardLinks                                    bool
+	sortSet, sortIgnoreCase, reverse, dirsFirst, filesFirst                                     bool
+	level, jobs, maxEntries, fileLimit                                                          int
+	timeout                                                                                     time.Duration
 	paths                                                                                       []string
+	matchPattern, ignorePattern, timeFmt, htmlBase, htmlTitle                                   string
+	hidden                                                                                      HiddenPolicy
+	sortKey                                                                                     SortKey
+	color                                                                                       colorMode
+
+	// FS is the file system the paths are resolved in; nil means the OS file system.
+	FS fs.FS
+	// Terminal tells that the output goes to a terminal, which turns on
+	// colors unless -n is given.
+	Terminal bool
 }
 
 const (
@@ -47,377 +59,384 @@ func NewTreeConfig() *TreeConfig {
 	return config
 }
 
-func ParseCommand(cmd string) TreeConfig {
-	//remove extra spaces in cmd
-	regxCmpl := regexp.MustCompile(`\s+`)
-	cmd = strings.TrimSpace(regxCmpl.ReplaceAllString(cmd, Space))
-	ca := strings.Split(cmd, Space) //args in command
-	if strings.TrimSpace(ca[0]) != Command {
-		log.Fatalf("command not found: `%v`", ca[0])
+// ParseCommand parses a tree command line given as a single string, split
+// on white space. Use ParseArgs for arguments that may contain spaces.
+func ParseCommand(cmd string) (TreeConfig, error) {
+	ca := strings.Fields(cmd) //args in command
+	if len(ca) == 0 || ca[0] != Command {
+		name := ""
+		if len(ca) > 0 {
+			name = ca[0]
+		}
+		return TreeConfig{}, &ParseError{Err: ErrUnknownCommand, Option: name}
 	}
+	return ParseArgs(ca[1:])
+}
 
+// ParseArgs parses the arguments of tree, without the command name, as in
+// os.Args[1:]. Short options can be combined as in -dfp, and take their
+// argument from the rest of the word (-L2) or from the next one (-L 2).
+// Long options take theirs as --level=2 or --level 2. Everything after --
+// is a path. Invalid arguments are reported with a *ParseError, --help with
+// ErrHelp.
+func ParseArgs(args []string) (TreeConfig, error) {
 	config := NewTreeConfig()
-	for i := 1; i < len(ca); i++ {
-		arg := ca[i] //op: option
-		switch arg {
-		case "-d":
-			config.reqOnlyDir = true
-		case "-f":
-			config.reqRelPath = true
-		case "-i":
-			config.noIndent = true
-		case "-J":
-			config.reqJsonFormat = true
-			config.reqXmlFormat = false
-		case "-L":
-			if len(ca) < i+1 {
-				log.Fatal("-L option requires value")
-			}
-			lVal := parseToInt(ca[i+1])
-			if lVal < 1 {
-				log.Fatal("-L value greater than 0")
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		// next returns the argument following the current one
+		next := func(option string) (string, error) {
+			if i+1 >= len(args) {
+				return "", &ParseError{Err: ErrMissingArgument, Option: option}
 			}
-			config.level = lVal
 			i++
-		case "-p":
-			config.reqFilePermsn = true
-		case "-t":
-			config.sortByModTime = true
-		case "-X":
-			config.reqXmlFormat = true
-			config.reqJsonFormat = false
+			return args[i], nil
+		}
+
+		var err error
+		switch {
+		case arg == "--":
+			config.paths = append(config.paths, args[i+1:]...)
+			i = len(args)
+		case strings.HasPrefix(arg, "--"):
+			err = config.parseLong(arg, next)
+		case len(arg) > 1 && arg[0] == '-':
+			err = config.parseShort(arg, next)
 		default:
-			// check for path
-			if !strings.HasPrefix(arg, "-") {
-				config.paths = append(config.paths, arg)
-				continue
-			}
-			log.Fatalf("Invalid argument `%v`", arg)
+			config.paths = append(config.paths, arg)
+		}
+		if err != nil {
+			return TreeConfig{}, err
 		}
 	}
 
 	if len(config.paths) < 1 {
 		config.paths = []string{"."}
 	}
-	return *config
+	return *config, nil
 }
 
-func ListDirAndFiles(config TreeConfig) string {
-	var fc FileCount
-	var isNthDirLast []bool
-	temp := ""
-	for _, p := range config.paths {
-		fc = FileCount{}
-		isNthDirLast = []bool{}
-		root := strings.TrimSuffix(p, PathSeperator)
-		if config.reqXmlFormat {
-			temp += recListDirAndFilesInXML(root, temp, 0, &fc, &config)
-			continue
-		}
-
-		if config.reqJsonFormat {
-			temp += recListDirAndFilesInJSON(root, temp, 0, &fc, &config)
-			continue
+// parseLong applies the long option arg, given as --name or --name=value.
+func (c *TreeConfig) parseLong(arg string, next func(option string) (string, error)) error {
+	name, v, inline := strings.Cut(arg, "=")
+	used := false
+	err := c.parseOption(name, func(option string) (string, error) {
+		used = true
+		if inline {
+			return v, nil
 		}
-		temp += recListDirAndFiles(root, root+NewLine, 0, &isNthDirLast, &fc, &config)
+		return next(option)
+	})
+	if err == nil && inline && !used {
+		err = invalidValue(name, v, "no value")
 	}
-	return formatRes(temp, fc, config)
+	return err
 }
 
-func recListDirAndFiles(root string, temp string, n int, isNthDirLast *[]bool, fc *FileCount, config *TreeConfig) string {
-	files := GetFiles(root, *config)
-	if len(files) < 1 || (config.level > 0 && n == config.level) {
-		*isNthDirLast = resizeToNMinus1(n, *isNthDirLast)
-		return temp
-	}
-
-	lastFile := files[len(files)-1]
-	for _, f := range files {
-		bp := getBeforePipeVal(n, *isNthDirLast, *config) // before pipe
-		isLastFile := lastFile == f
-		pipe := getPipeVal(isLastFile, *config) // pipe (│── or └──)
-		ap := getAfterPipeVal(root, f, *config) // after pipe
-		temp += bp + pipe + ap + NewLine        //line structure in tree
-
-		if !f.IsDir() { // file
-			fc.fileCnt++
-			continue
+// parseShort applies the short options combined in arg, such as -dfp. An
+// option taking an argument ends the group; the rest of the word, if any,
+// is its argument.
+func (c *TreeConfig) parseShort(arg string, next func(option string) (string, error)) error {
+	flags := []rune(arg[1:])
+	for j := 0; j < len(flags); j++ {
+		rest := string(flags[j+1:])
+		err := c.parseOption("-"+string(flags[j]), func(option string) (string, error) {
+			if rest == "" {
+				return next(option)
+			}
+			j = len(flags)
+			return rest, nil
+		})
+		if err != nil {
+			return err
 		}
-		fc.dirCnt++
-		//tracking information(whether directory last or not) from 0 to Nth level directory
-		*isNthDirLast = append(*isNthDirLast, isLastFile)
-		temp = recListDirAndFiles(root+PathSeperator+f.Name(), temp, n+1, isNthDirLast, fc, config)
 	}
-	*isNthDirLast = resizeToNMinus1(n, *isNthDirLast)
-	return temp
+	return nil
 }
 
-func recListDirAndFilesInXML(root string, temp string, n int, fc *FileCount, config *TreeConfig) string {
-	files := GetFiles(root, *config)
-
-	if n == 0 {
-		temp += strings.Repeat(Space, n+2) + OpenTag + "directory name=\"" + root + CloseTag + NewLine
-	}
-
-	closeDirTag := OpenTag + Slash + "directory" + CloseTag + NewLine
-	if n > 0 && n == config.level {
-		return temp + strings.Repeat(Space, n+3) + closeDirTag
-	}
-
-	for _, f := range files {
-		if !f.IsDir() { // file
-			temp += strings.Repeat(Space, n+4) + OpenTag + "file" + getFileAttrsVal(f, *config) + CloseTag +
-				OpenTag + Slash + "file" + CloseTag + NewLine
-			fc.fileCnt++
-			continue
+// parseOption applies the option arg, such as -L or --sort, to c, taking
+// its argument from value.
+func (c *TreeConfig) parseOption(arg string, value func(option string) (string, error)) error {
+	var err error
+	switch arg {
+	case "-a":
+		c.hidden = HiddenAll
+	case "-d":
+		c.reqOnlyDir = true
+	case "-f":
+		c.reqRelPath = true
+	case "-l":
+		c.followLinks = true
+	case "-i":
+		c.noIndent = true
+	case "-J":
+		c.reqJsonFormat = true
+		c.reqXmlFormat, c.reqHtmlFormat = false, false
+	case "-L", "--level":
+		var v string
+		if v, err = value(arg); err == nil {
+			c.level, err = parseCount(arg, v)
 		}
-		temp += strings.Repeat(Space, n+4) + OpenTag + "directory" + getFileAttrsVal(f, *config) + CloseTag + NewLine
-		fc.dirCnt++
-		temp = recListDirAndFilesInXML(root+PathSeperator+f.Name(), temp, n+1, fc, config)
-	}
-
-	if n > 0 {
-		return temp + strings.Repeat(Space, n+3) + closeDirTag
-	}
-	return temp + strings.Repeat(Space, n+2) + closeDirTag
-}
-
-func recListDirAndFilesInJSON(root string, temp string, n int, fc *FileCount, config *TreeConfig) string {
-	files := GetFiles(root, *config)
-
-	if n == 0 {
-		temp += strings.Repeat(Space, n+2) + "{\"type\":\"directory\",\"name\":\"" + root + "\",\"contents\":[" + NewLine
-	}
-
-	if n > 0 && n == config.level {
-		return temp + strings.Repeat(Space, n+3) + JSONArrEnd + NewLine
-	}
-
-	var lastFile fs.DirEntry
-	if len(files) > 0 {
-		lastFile = files[len(files)-1]
-	}
-
-	for _, f := range files {
-		isLastFile := f == lastFile
-		if !f.IsDir() { // file
-			temp += strings.Repeat(Space, n+4) + "{\"type\":\"file\"" + getFileAttrsVal(f, *config) + "}"
-			if !isLastFile {
-				temp += ","
-			}
-			temp += NewLine
-			fc.fileCnt++
-			continue
+	case "-p":
+		c.reqFilePermsn = true
+	case "-u":
+		c.reqUser = true
+	case "-g":
+		c.reqGroup = true
+	case "--inodes":
+		c.reqInode = true
+	case "--device":
+		c.reqDevice = true
+	case "-s":
+		c.reqSize = true
+	case "-h":
+		c.humanSize = true
+	case "--si":
+		c.siUnits = true
+	case "--du":
+		c.du = true
+	case "-t":
+		c.sortKey = SortModTime
+		c.sortSet = true
+	case "-c":
+		c.sortKey = SortChangeTime
+		c.sortSet = true
+		c.changeTime = true
+	case "-v":
+		c.sortKey = SortVersion
+		c.sortSet = true
+	case "-U":
+		c.sortKey = SortNone
+		c.sortSet = true
+	case "-r":
+		c.reverse = true
+	case "--sort-ignore-case":
+		c.sortIgnoreCase = true
+	case "--dirsfirst":
+		c.dirsFirst = true
+		c.filesFirst = false
+	case "--filesfirst":
+		c.filesFirst = true
+		c.dirsFirst = false
+	case "--sort":
+		var v string
+		if v, err = value(arg); err == nil {
+			c.sortKey, err = parseSortKey(arg, v)
+			c.sortSet = true
 		}
-		temp += strings.Repeat(Space, n+4) + "{\"type\":\"directory\"" + getFileAttrsVal(f, *config) + ",\"contents\":[" + NewLine
-		fc.dirCnt++
-		temp = recListDirAndFilesInJSON(root+PathSeperator+f.Name(), temp, n+1, fc, config)
-	}
-
-	if n > 0 {
-		temp += strings.Repeat(Space, n+3) + JSONArrEnd
-		if len(files) > 1 {
-			temp += ","
+	case "-D":
+		c.reqTime = true
+	case "--timefmt":
+		c.timeFmt, err = value(arg)
+	case "--jobs":
+		var v string
+		if v, err = value(arg); err == nil {
+			c.jobs, err = parseCount(arg, v)
 		}
-		return temp + NewLine
-	}
-	return temp + strings.Repeat(Space, n+2) + JSONArrEnd + NewLine
-}
-
-func GetFiles(root string, config TreeConfig) []fs.DirEntry {
-	files := IgnoreDotFiles(ReadDir(root))
-	if config.reqOnlyDir {
-		files = ReadOnlyDir(files)
-	}
-
-	if config.sortByModTime {
-		SortByModTime(files)
-	}
-	return files
-}
-
-func ReadDir(root string) []fs.DirEntry {
-	files, err := os.ReadDir(root)
-	if err != nil {
-		fmt.Println(err)
-		return make([]fs.DirEntry, 0)
-	}
-	return files
-}
-
-func IgnoreDotFiles(files []fs.DirEntry) []fs.DirEntry {
-	fs := make([]fs.DirEntry, 0)
-	for _, f := range files {
-		if !strings.HasPrefix(f.Name(), ".") {
-			fs = append(fs, f)
+	case "--filelimit":
+		var v string
+		if v, err = value(arg); err == nil {
+			c.fileLimit, err = parseCount(arg, v)
+		}
+	case "--max-entries":
+		var v string
+		if v, err = value(arg); err == nil {
+			c.maxEntries, err = parseCount(arg, v)
 		}
+	case "--timeout":
+		var v string
+		if v, err = value(arg); err == nil {
+			c.timeout, err = parseTimeout(arg, v)
+		}
+	case "-H":
+		c.reqHtmlFormat = true
+		c.reqJsonFormat, c.reqXmlFormat = false, false
+		c.htmlBase, err = value(arg)
+	case "--title":
+		c.htmlTitle, err = value(arg)
+	case "-C":
+		c.color = colorAlways
+	case "-n":
+		c.color = colorNever
+	case "--stats":
+		c.stats = true
+	case "--noreport":
+		c.noReport = true
+	case "--archives":
+		c.archives = true
+	case "-x":
+		c.oneFS = true
+	case "--hardlinks":
+		c.hardLinks = true
+	case "-P":
+		c.matchPattern, err = patternValue(arg, value)
+	case "-I":
+		c.ignorePattern, err = patternValue(arg, value)
+	case "--ignore-case":
+		c.ignoreCase = true
+	case "--matchdirs":
+		c.matchDirs = true
+	case "--prune":
+		c.prune = true
+	case "--gitignore":
+		c.gitignore = true
+	case "-X":
+		c.reqXmlFormat = true
+		c.reqJsonFormat, c.reqHtmlFormat = false, false
+	case "--hidden":
+		var v string
+		if v, err = value(arg); err == nil {
+			var valid bool
+			if c.hidden, valid = ParseHiddenPolicy(v); !valid {
+				err = invalidValue(arg, v, "none, all or config")
+			}
+		}
+	case "--help":
+		return ErrHelp
+	default:
+		return &ParseError{Err: ErrUnknownOption, Option: arg}
 	}
-	return fs
+	return err
 }
 
-func ReadOnlyDir(files []fs.DirEntry) []fs.DirEntry {
-	dirs := make([]fs.DirEntry, 0)
-	for _, f := range files {
-		if f.IsDir() {
-			dirs = append(dirs, f)
-		}
-	}
-	return dirs
+// ListDirAndFiles renders the tree described by config and returns it as a string.
+func ListDirAndFiles(config TreeConfig) string {
+	var sb strings.Builder
+	_ = WriteTree(&sb, config) // failures are noted in the listing itself
+	return strings.TrimSuffix(sb.String(), NewLine)
 }
 
-func SortByModTime(files []fs.DirEntry) {
-	sort.Slice(files, func(i, j int) bool {
-		return getFileInfo(files[i]).ModTime().Unix() < getFileInfo(files[j]).ModTime().Unix()
-	})
+// WriteTree renders the tree described by config into w, emitting lines as
+// the walk progresses instead of buffering the whole listing.
+func WriteTree(w io.Writer, config TreeConfig) error {
+	return WriteTreeContext(context.Background(), w, config)
 }
 
-func resizeToNMinus1(n int, sl []bool) []bool {
-	if n > 0 && len(sl) > 0 {
-		sl = sl[:n-1]
-	}
-	return sl
+// WriteTreeContext is WriteTree with a context; see RenderTreeContext.
+func WriteTreeContext(ctx context.Context, w io.Writer, config TreeConfig) error {
+	return RenderTreeContext(ctx, NewRenderer(w, config), config)
 }
 
-func getFileInfo(fs fs.DirEntry) fs.FileInfo {
-	fi, err := fs.Info()
-	if err != nil {
-		fmt.Println(OpenBrkt + err.Error() + CloseBrkt)
-		return fi
-	}
-	return fi
+// RenderTree walks every path in config once and feeds the nodes to r. When
+// some of the tree could not be read it returns a *WalkError after rendering
+// the rest.
+func RenderTree(r Renderer, config TreeConfig) error {
+	return RenderTreeContext(context.Background(), r, config)
 }
 
-func getBeforePipeVal(n int, isNthDirLast []bool, config TreeConfig) string {
-	bp := ""
-	if config.noIndent {
-		return bp
+// RenderTreeContext is RenderTree stopping early when ctx is done, when the
+// --timeout of config runs out or after its --max-entries entries. The
+// entries listed so far are still rendered as a complete document, with
+// Report.Truncated telling why it ends early; a walk stopped by ctx or the
+// timeout also returns the context error, joined with any *WalkError.
+func RenderTreeContext(ctx context.Context, r Renderer, config TreeConfig) error {
+	if config.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.timeout)
+		defer cancel()
+	}
+	if err := r.Begin(); err != nil {
+		return err
+	}
+	start := time.Now()
+	wk := newWalker(&config, r)
+	wk.ctx = ctx
+	defer wk.startJobs()()
+	var total Report
+	for i, p := range config.paths {
+		if i > 0 && wk.stop() {
+			break
+		}
+		wk.report = Report{}
+		root := wk.newRoot(p)
+		if err := wk.visit(root, Position{Last: i == len(config.paths)-1}); err != nil {
+			return err
+		}
+		total.Directories += wk.report.Directories
+		total.Files += wk.report.Files
+		total.Size += wk.report.Size
+		if len(config.paths) > 1 {
+			total.Roots = append(total.Roots, RootReport{root.Path, wk.report.Directories, wk.report.Files, wk.report.Size})
+		}
 	}
-
-	if n < 1 || n > len(isNthDirLast) {
-		return bp
+	total.Truncated = wk.truncated
+	if config.stats {
+		total.Stats = wk.calls.stats(time.Since(start))
 	}
-
-	for i := 0; i < n; i++ {
-		if !isNthDirLast[i] {
-			bp += BoxVer + Spaces3
-			continue
-		}
-		bp += Spaces4
+	if err := r.End(total); err != nil {
+		return err
 	}
-	return bp
-}
-
-func getPipeVal(isLastFile bool, config TreeConfig) string {
-	pipe := BoxVH //
-	if isLastFile {
-		pipe = BoxUpAndRig + BoxHor // └──
+	var errs []error
+	if err := ctx.Err(); err != nil && wk.truncated != "" {
+		errs = append(errs, fmt.Errorf("listing truncated: %w", err))
 	}
-	if config.noIndent {
-		pipe = ""
+	if len(wk.errs) > 0 {
+		errs = append(errs, &WalkError{Errs: wk.errs})
 	}
-	return pipe
+	return errors.Join(errs...)
 }
 
-func getAfterPipeVal(root string, fi fs.DirEntry, config TreeConfig) string {
-	ap := Space + fi.Name() //after pipe
-	var relPath, fp string  // fp: file permission
-
-	if config.reqRelPath {
-		relPath = Space + root + PathSeperator + fi.Name()
-		ap = relPath
+// NewRenderer returns the renderer for the output format selected in config.
+func NewRenderer(w io.Writer, config TreeConfig) Renderer {
+	if config.reqHtmlFormat {
+		return NewHTMLRenderer(w, config)
 	}
 
-	if config.reqFilePermsn {
-		fp = Space + OpenBrkt + getPermsnMode(fi, false) + CloseBrkt + Space
-		ap = fp + fi.Name()
+	if config.reqXmlFormat {
+		return NewXMLRenderer(w, config)
 	}
 
-	if config.reqRelPath && config.reqFilePermsn {
-		ap = fp + relPath
+	if config.reqJsonFormat {
+		return NewJSONRenderer(w, config)
 	}
-	return ap
+	return NewTextRenderer(w, config)
 }
 
-func getPermsnMode(f fs.DirEntry, inOctal bool) string {
-	fi, err := f.Info()
-	if err != nil {
-		fmt.Println(err)
-		return "Unable read mode"
-	}
-	perm := fi.Mode()
-	res := perm.String()
-	if inOctal {
-		res = fmt.Sprintf("%#o", perm.Perm())
-	}
-	return res
+// treeWriter keeps the first write error so renderers do not have to
+// check every string they emit.
+type treeWriter struct {
+	w   io.Writer
+	err error
 }
 
-func getFileAttrsVal(file fs.DirEntry, config TreeConfig) string {
-	attrs := ""
-	if config.reqXmlFormat {
-		attrs = " name=\"" + file.Name() + "\""
-		if config.reqFilePermsn {
-			attrs += " mode=\"" + getPermsnMode(file, true) + "\""
-			attrs += " prot=\"" + getPermsnMode(file, false) + "\""
+func (tw *treeWriter) print(s ...string) {
+	for _, v := range s {
+		if tw.err != nil {
+			return
 		}
+		_, tw.err = io.WriteString(tw.w, v)
 	}
-	if config.reqJsonFormat {
-		attrs = ",\"name\":\"" + file.Name() + "\""
-		if config.reqFilePermsn {
-			attrs += ",\"mode\":\"" + getPermsnMode(file, true) + "\""
-			attrs += ",\"prot\":\"" + getPermsnMode(file, false) + "\""
-		}
-	}
-	return attrs
 }
 
-func formatRes(temp string, fc FileCount, config TreeConfig) string {
-	op := ""
-	dirStr := ""
-	fileStr := ""
-	if !config.reqXmlFormat && !config.reqJsonFormat {
-		dirStr = fmt.Sprintf("%v directories", fc.dirCnt)
-		if fc.dirCnt == 1 {
-			dirStr = fmt.Sprintf("%v directory", fc.dirCnt)
-		}
-
-		fileStr = fmt.Sprintf("%v files", fc.fileCnt)
-		if fc.fileCnt == 1 {
-			fileStr = fmt.Sprintf("%v file", fc.fileCnt)
-		}
-		op = fmt.Sprintf("%v%v%v", temp, NewLine, dirStr)
-		if !config.reqOnlyDir {
-			op = fmt.Sprintf("%v, %v", op, fileStr)
-		}
+func parseSortKey(option, v string) (SortKey, error) {
+	k, ok := ParseSortKey(v)
+	if !ok {
+		return k, invalidValue(option, v, "one of "+strings.Join(sortKeyNames, ", "))
 	}
+	return k, nil
+}
 
-	if config.reqXmlFormat {
-		header := "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n"
-		dirStr = fmt.Sprintf("<directories>%v</directories>", fc.dirCnt)
-		fileStr = fmt.Sprintf("<files>%v</files>", fc.dirCnt)
-		report := fmt.Sprintf("  <report>\n   %v\n   %v\n  </report>", dirStr, fileStr)
-		if config.reqOnlyDir {
-			report = fmt.Sprintf("  <report>\n   %v\n  </report>", dirStr)
-		}
-		op = fmt.Sprintf("%v<%v>\n%v%v\n</%v>", header, Command, temp, report, Command)
+// parseCount parses the value of options such as -L that take a number
+// greater than 0.
+func parseCount(option, v string) (int, error) {
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return 0, invalidValue(option, v, "a number greater than 0")
 	}
+	return n, nil
+}
 
-	if config.reqJsonFormat {
-		op = fmt.Sprintf("[\n%v,\n  {\"type\":\"report\",\"directories\":%v", temp, fc.dirCnt)
-		if !config.reqOnlyDir {
-			op = fmt.Sprintf("%v,\"files\":%v}\n]", op, fc.fileCnt)
-		}
+func parseTimeout(option, v string) (time.Duration, error) {
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, invalidValue(option, v, "a positive duration such as 10s")
 	}
-
-	return op
+	return d, nil
 }
 
-func parseToInt(input string) int {
-	num, err := strconv.ParseInt(input, 10, 32)
-	//check for error
-	if err != nil {
-		log.Println(err.Error())
-		return 0
+func patternValue(option string, value func(option string) (string, error)) (string, error) {
+	v, err := value(option)
+	if err == nil && !validPattern(v) {
+		err = invalidValue(option, v, "a valid pattern")
 	}
-	return int(num)
+	return v, err
 }
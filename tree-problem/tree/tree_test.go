@@ -1,7 +1,12 @@
 package tree
 
 import (
+	"io/fs"
+	"os"
+	"path/filepath"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -10,25 +15,73 @@ type test struct {
 	cmd  string
 	desc string
 	want string
+	fsys fs.FS // defaults to testFS()
+}
+
+var epoch = time.Date(2022, time.December, 1, 0, 0, 0, 0, time.UTC)
+
+// dir and file build fixture entries; age orders them for -t.
+func dir(age int) *fstest.MapFile {
+	return &fstest.MapFile{Mode: fs.ModeDir | 0755, ModTime: epoch.Add(time.Duration(age) * time.Minute)}
+}
+
+func file(age int) *fstest.MapFile {
+	return &fstest.MapFile{Mode: 0644, ModTime: epoch.Add(time.Duration(age) * time.Minute)}
+}
+
+// mustParse parses cmd, failing the test when it is rejected.
+func mustParse(t *testing.T, cmd string) TreeConfig {
+	t.Helper()
+	config, err := ParseCommand(cmd)
+	assert.NoError(t, err, cmd)
+	return config
+}
+
+func testFS() fstest.MapFS {
+	testDir := fstest.MapFS{
+		"test-dir":                     dir(0),
+		"test-dir/empty":               dir(1),
+		"test-dir/hello":               dir(2),
+		"test-dir/hello/hello.txt":     file(3),
+		"test-dir/hello/temp":          dir(4),
+		"test-dir/hello/temp/temp.txt": file(5),
+		"test-dir/hello/xelo":          dir(6),
+		"test-dir/hello/xelo/lwlo.rx":  file(7),
+	}
+
+	fsys := fstest.MapFS{
+		"resources":                                    dir(0),
+		"resources/level-test-dir":                     dir(0),
+		"resources/level-test-dir/in":                  dir(1),
+		"resources/level-test-dir/in/one2n":            dir(1),
+		"resources/level-test-dir/in/one2n/tree-prblm": dir(1),
+		"resources/level-test-dir/META-INF":            dir(10),
+		"resources/level-test-dir/META-INF/empty":      dir(10),
+	}
+	for name, f := range testDir {
+		fsys["resources/"+name] = f
+		fsys["resources/level-test-dir/in/one2n/tree-prblm/"+name] = f
+	}
+	return fsys
 }
 
 func TestListDirAndFiles(t *testing.T) {
 
 	tests := []test{
-		{cmd: "tree ../resources/test-dir/empty", desc: "empty dir test",
-			want: "../resources/test-dir/empty\n" +
+		{cmd: "tree resources/test-dir/empty", desc: "empty dir test",
+			want: "resources/test-dir/empty\n" +
 				  "\n0 directories, 0 files"},
-		{cmd: "tree ", desc: "No paths in command test",
+		{cmd: "tree ", desc: "No paths in command test", fsys: fstest.MapFS{"tree.go": file(0), "tree_test.go": file(1)},
 			want: ".\n"+
 			      "│── tree.go\n"+
 				  "└── tree_test.go\n\n"+
 				  "0 directories, 2 files"},
-		{cmd: "tree ../resources/test-dir/hello/temp", desc: "Single file in directory test",
-			want: "../resources/test-dir/hello/temp\n"+
+		{cmd: "tree resources/test-dir/hello/temp", desc: "Single file in directory test",
+			want: "resources/test-dir/hello/temp\n"+
 			      "└── temp.txt\n\n"+
 				  "0 directories, 1 file"},
-		{cmd: "tree ../resources/test-dir/", desc: "directory with multiple files test",
-			want: "../resources/test-dir\n"+
+		{cmd: "tree resources/test-dir/", desc: "directory with multiple files test",
+			want: "resources/test-dir\n"+
 			      "│── empty\n"+
 				  "└── hello\n"+
 				  "    │── hello.txt\n" +
@@ -37,18 +90,18 @@ func TestListDirAndFiles(t *testing.T) {
 				  "    └── xelo\n"+
 				  "        └── lwlo.rx\n" +
 			      "\n4 directories, 3 files"},
-		{cmd: "tree -f ../resources/test-dir/", desc: "relative path directories test",
-			want: "../resources/test-dir\n"+
-			      "│── ../resources/test-dir/empty\n"+
-				  "└── ../resources/test-dir/hello\n"+
-				  "    │── ../resources/test-dir/hello/hello.txt\n" +
-				  "    │── ../resources/test-dir/hello/temp\n"+
-				  "    │   └── ../resources/test-dir/hello/temp/temp.txt\n"+
-				  "    └── ../resources/test-dir/hello/xelo\n"+
-				  "        └── ../resources/test-dir/hello/xelo/lwlo.rx\n\n"+
+		{cmd: "tree -f resources/test-dir/", desc: "relative path directories test",
+			want: "resources/test-dir\n"+
+			      "│── resources/test-dir/empty\n"+
+				  "└── resources/test-dir/hello\n"+
+				  "    │── resources/test-dir/hello/hello.txt\n" +
+				  "    │── resources/test-dir/hello/temp\n"+
+				  "    │   └── resources/test-dir/hello/temp/temp.txt\n"+
+				  "    └── resources/test-dir/hello/xelo\n"+
+				  "        └── resources/test-dir/hello/xelo/lwlo.rx\n\n"+
 				  "4 directories, 3 files"},
-		{cmd: "tree -p ../resources/test-dir/", desc: "Files with permission mode test",
-			want: "../resources/test-dir\n"+
+		{cmd: "tree -p resources/test-dir/", desc: "Files with permission mode test",
+			want: "resources/test-dir\n"+
 			      "│── [drwxr-xr-x] empty\n"+
 				  "└── [drwxr-xr-x] hello\n" +
 				  "    │── [-rw-r--r--] hello.txt\n"+
@@ -57,8 +110,8 @@ func TestListDirAndFiles(t *testing.T) {
 				  "    └── [drwxr-xr-x] xelo\n"+
 				  "        └── [-rw-r--r--] lwlo.rx\n\n" +
 				 "4 directories, 3 files"},
-		{cmd: "tree -t ../resources/test-dir/", desc: "Order files by Modified Time(-t) test",
-			want: "../resources/test-dir\n"+
+		{cmd: "tree -t resources/test-dir/", desc: "Order files by Modified Time(-t) test",
+			want: "resources/test-dir\n"+
 			      "│── empty\n"+
 				  "└── hello\n"+
 				  "    │── hello.txt\n" +
@@ -67,28 +120,28 @@ func TestListDirAndFiles(t *testing.T) {
 				  "    └── xelo\n"+
 				  "        └── lwlo.rx\n\n" +
 			      "4 directories, 3 files"},
-		{cmd: "tree -i -f ../resources/test-dir/", desc: "List files with 'No-Indentation' test",
-			want: "../resources/test-dir\n"+
-			      " ../resources/test-dir/empty\n"+
-				  " ../resources/test-dir/hello\n" +
-				  " ../resources/test-dir/hello/hello.txt\n"+
-				  " ../resources/test-dir/hello/temp\n" +
-				  " ../resources/test-dir/hello/temp/temp.txt\n"+
-				  " ../resources/test-dir/hello/xelo\n" +
-				  " ../resources/test-dir/hello/xelo/lwlo.rx\n\n"+
+		{cmd: "tree -i -f resources/test-dir/", desc: "List files with 'No-Indentation' test",
+			want: "resources/test-dir\n"+
+			      " resources/test-dir/empty\n"+
+				  " resources/test-dir/hello\n" +
+				  " resources/test-dir/hello/hello.txt\n"+
+				  " resources/test-dir/hello/temp\n" +
+				  " resources/test-dir/hello/temp/temp.txt\n"+
+				  " resources/test-dir/hello/xelo\n" +
+				  " resources/test-dir/hello/xelo/lwlo.rx\n\n"+
 				  "4 directories, 3 files"},
-		{cmd: "tree -p -f ../resources/test-dir/", desc: "permission mode and relative path test",
-			want: "../resources/test-dir\n"+
-			      "│── [drwxr-xr-x]  ../resources/test-dir/empty\n"+
-				  "└── [drwxr-xr-x]  ../resources/test-dir/hello\n"+
-				  "    │── [-rw-r--r--]  ../resources/test-dir/hello/hello.txt\n" +
-				  "    │── [drwxr-xr-x]  ../resources/test-dir/hello/temp\n"+
-				  "    │   └── [-rw-r--r--]  ../resources/test-dir/hello/temp/temp.txt\n"+
-				  "    └── [drwxr-xr-x]  ../resources/test-dir/hello/xelo\n"+
-				  "        └── [-rw-r--r--]  ../resources/test-dir/hello/xelo/lwlo.rx\n\n"+
+		{cmd: "tree -p -f resources/test-dir/", desc: "permission mode and relative path test",
+			want: "resources/test-dir\n"+
+			      "│── [drwxr-xr-x]  resources/test-dir/empty\n"+
+				  "└── [drwxr-xr-x]  resources/test-dir/hello\n"+
+				  "    │── [-rw-r--r--]  resources/test-dir/hello/hello.txt\n" +
+				  "    │── [drwxr-xr-x]  resources/test-dir/hello/temp\n"+
+				  "    │   └── [-rw-r--r--]  resources/test-dir/hello/temp/temp.txt\n"+
+				  "    └── [drwxr-xr-x]  resources/test-dir/hello/xelo\n"+
+				  "        └── [-rw-r--r--]  resources/test-dir/hello/xelo/lwlo.rx\n\n"+
 				  "4 directories, 3 files"},
-		{cmd: "tree -L 5 ../resources/level-test-dir", desc: "Level 5 directories test",
-			want: "../resources/level-test-dir\n"+
+		{cmd: "tree -L 5 resources/level-test-dir", desc: "Level 5 directories test",
+			want: "resources/level-test-dir\n"+
 			      "│── META-INF\n"+
 				  "│   └── empty\n"+
 				  "└── in\n"+
@@ -98,8 +151,8 @@ func TestListDirAndFiles(t *testing.T) {
 				  "                │── empty\n"+
 				  "                └── hello\n\n"+
 				  "8 directories, 0 files"},
-		{cmd: "tree -L 7 -d ../resources/level-test-dir", desc: "only directories upto 7 levels test",
-			want: "../resources/level-test-dir\n"+
+		{cmd: "tree -L 7 -d resources/level-test-dir", desc: "only directories upto 7 levels test",
+			want: "resources/level-test-dir\n"+
 			      "│── META-INF\n"+
 				  "│   └── empty\n"+
 				  "└── in\n" +
@@ -111,8 +164,8 @@ func TestListDirAndFiles(t *testing.T) {
 				  "                    │── temp\n"+
 				  "                    └── xelo\n\n"+
 				  "10 directories"},
-		{cmd: "tree  -L            7   -d -t      -p ../resources/level-test-dir", desc: "Parsing command with odd spaces and mutiple args test",
-			want: "../resources/level-test-dir\n"+
+		{cmd: "tree  -L            7   -d -t      -p resources/level-test-dir", desc: "Parsing command with odd spaces and mutiple args test",
+			want: "resources/level-test-dir\n"+
 			"│── [drwxr-xr-x] in\n"+
 			"│   └── [drwxr-xr-x] one2n\n"+
 			"│       └── [drwxr-xr-x] tree-prblm\n"+
@@ -124,8 +177,8 @@ func TestListDirAndFiles(t *testing.T) {
 			"└── [drwxr-xr-x] META-INF\n"+
 			"    └── [drwxr-xr-x] empty\n\n"+
 			"10 directories"},
-		{cmd: "tree ../resources/level-test-dir ../resources/test-dir", desc: "Parsing command with odd spaces and mutiple args test",
-			want: "../resources/level-test-dir\n"+
+		{cmd: "tree resources/level-test-dir resources/test-dir", desc: "Parsing command with odd spaces and mutiple args test",
+			want: "resources/level-test-dir\n"+
 			      "│── META-INF\n"+
 				  "│   └── empty\n"+
 				  "└── in\n"+
@@ -139,7 +192,7 @@ func TestListDirAndFiles(t *testing.T) {
 				  "                    │   └── temp.txt\n"+
 				  "                    └── xelo\n"+
 				  "                        └── lwlo.rx\n" +
-				  "../resources/test-dir\n"+
+				  "resources/test-dir\n"+
 				  "│── empty\n"+
 				  "└── hello\n"+
 				  "    │── hello.txt\n"+
@@ -147,67 +200,138 @@ func TestListDirAndFiles(t *testing.T) {
 				  "    │   └── temp.txt\n"+
 				  "    └── xelo\n"+
 				  "        └── lwlo.rx\n\n"+
-				  "4 directories, 3 files"},
-		{cmd: "tree -X ../resources/test-dir/empty", desc: "XML format empty dir test",
-			want: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<tree>\n  " +
-				"<directory name=\"../resources/test-dir/empty>\n  </directory>\n  <report>\n   " +
-				"<directories>0</directories>\n   <files>0</files>\n  </report>\n</tree>"},
-		{cmd: "tree -X ../resources/test-dir/hello/temp", desc: "XML format single file in directory test",
-			want: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<tree>\n  " +
-				"<directory name=\"../resources/test-dir/hello/temp>\n    <file name=\"temp.txt\"></file>\n " +
-				" </directory>\n  <report>\n   <directories>0</directories>\n   <files>0</files>\n  " +
-				"</report>\n</tree>"},
-		{cmd: "tree -X -L 5 ../resources/level-test-dir", desc: "XML format Level 5 directories test",
-			want: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<tree>\n  " +
-				"<directory name=\"../resources/level-test-dir>\n    <directory name=\"META-INF\">\n     " +
-				"<directory name=\"empty\">\n     </directory>\n    </directory>\n    <directory name=\"in\">\n" +
-				"     <directory name=\"one2n\">\n      <directory name=\"tree-prblm\">\n       " +
-				"<directory name=\"test-dir\">\n        <directory name=\"empty\">\n        </directory>\n" +
-				"        <directory name=\"hello\">\n        </directory>\n       </directory>\n      " +
-				"</directory>\n     </directory>\n    </directory>\n  </directory>\n  <report>\n   " +
-				"<directories>8</directories>\n   <files>8</files>\n  </report>\n</tree>"},
-		{cmd: "tree -p -X ../resources/test-dir/", desc: "Files in XML format with permission mode test",
-			want: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<tree>\n  " +
-				"<directory name=\"../resources/test-dir>\n    " +
-				"<directory name=\"empty\" mode=\"0755\" prot=\"drwxr-xr-x\">\n    </directory>\n    " +
-				"<directory name=\"hello\" mode=\"0755\" prot=\"drwxr-xr-x\">\n     " +
-				"<file name=\"hello.txt\" mode=\"0644\" prot=\"-rw-r--r--\"></file>\n     " +
-				"<directory name=\"temp\" mode=\"0755\" prot=\"drwxr-xr-x\">\n      " +
-				"<file name=\"temp.txt\" mode=\"0644\" prot=\"-rw-r--r--\"></file>\n     " +
-				"</directory>\n     <directory name=\"xelo\" mode=\"0755\" prot=\"drwxr-xr-x\">\n      " +
-				"<file name=\"lwlo.rx\" mode=\"0644\" prot=\"-rw-r--r--\"></file>\n     </directory>\n" +
-				"    </directory>\n  </directory>\n  <report>\n   <directories>4</directories>\n   " +
-				"<files>4</files>\n  </report>\n</tree>"},
-		{cmd: "tree -X -p -d ../resources/test-dir/", desc: "XML format only directories and permission mode test",
-			want: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<tree>\n  " +
-				"<directory name=\"../resources/test-dir>\n    " +
-				"<directory name=\"empty\" mode=\"0755\" prot=\"drwxr-xr-x\">\n    " +
-				"</directory>\n    <directory name=\"hello\" mode=\"0755\" prot=\"drwxr-xr-x\">\n     " +
-				"<directory name=\"temp\" mode=\"0755\" prot=\"drwxr-xr-x\">\n     </directory>\n     " +
-				"<directory name=\"xelo\" mode=\"0755\" prot=\"drwxr-xr-x\">\n     </directory>\n    " +
-				"</directory>\n  </directory>\n  <report>\n   <directories>4</directories>\n  </report>\n</tree>"},
-		{cmd: "tree -J ../resources/test-dir/empty", desc: "JSON format empty dir test",
-			want: "[\n  {\"type\":\"directory\",\"name\":\"../resources/test-dir/empty\",\"contents\":[\n" +
+				  "resources/level-test-dir: 10 directories, 3 files\n"+
+				  "resources/test-dir: 4 directories, 3 files\n"+
+				  "14 directories, 6 files"},
+		{cmd: "tree -X resources/test-dir/empty", desc: "XML format empty dir test",
+			want: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<tree>\n" +
+				"  <directory name=\"resources/test-dir/empty\">\n  </directory>\n  <report>\n" +
+				"    <directories>0</directories>\n    <files>0</files>\n  </report>\n</tree>"},
+		{cmd: "tree -X resources/test-dir/hello/temp", desc: "XML format single file in directory test",
+			want: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<tree>\n" +
+				"  <directory name=\"resources/test-dir/hello/temp\">\n    <file name=\"temp.txt\"></file>\n" +
+				"  </directory>\n  <report>\n    <directories>0</directories>\n    <files>1</files>\n" +
+				"  </report>\n</tree>"},
+		{cmd: "tree -X -L 5 resources/level-test-dir", desc: "XML format Level 5 directories test",
+			want: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<tree>\n" +
+				"  <directory name=\"resources/level-test-dir\">\n    <directory name=\"META-INF\">\n" +
+				"      <directory name=\"empty\">\n      </directory>\n    </directory>\n    <directory name=\"in\">\n" +
+				"      <directory name=\"one2n\">\n        <directory name=\"tree-prblm\">\n" +
+				"          <directory name=\"test-dir\">\n            <directory name=\"empty\">\n            </directory>\n" +
+				"            <directory name=\"hello\">\n            </directory>\n          </directory>\n" +
+				"        </directory>\n      </directory>\n    </directory>\n  </directory>\n  <report>\n" +
+				"    <directories>8</directories>\n    <files>0</files>\n  </report>\n</tree>"},
+		{cmd: "tree -p -X resources/test-dir/", desc: "Files in XML format with permission mode test",
+			want: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<tree>\n" +
+				"  <directory name=\"resources/test-dir\">\n" +
+				"    <directory name=\"empty\" mode=\"0755\" prot=\"drwxr-xr-x\">\n    </directory>\n" +
+				"    <directory name=\"hello\" mode=\"0755\" prot=\"drwxr-xr-x\">\n" +
+				"      <file name=\"hello.txt\" mode=\"0644\" prot=\"-rw-r--r--\"></file>\n" +
+				"      <directory name=\"temp\" mode=\"0755\" prot=\"drwxr-xr-x\">\n" +
+				"        <file name=\"temp.txt\" mode=\"0644\" prot=\"-rw-r--r--\"></file>\n" +
+				"      </directory>\n      <directory name=\"xelo\" mode=\"0755\" prot=\"drwxr-xr-x\">\n" +
+				"        <file name=\"lwlo.rx\" mode=\"0644\" prot=\"-rw-r--r--\"></file>\n      </directory>\n" +
+				"    </directory>\n  </directory>\n  <report>\n    <directories>4</directories>\n" +
+				"    <files>3</files>\n  </report>\n</tree>"},
+		{cmd: "tree -X -p -d resources/test-dir/", desc: "XML format only directories and permission mode test",
+			want: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<tree>\n" +
+				"  <directory name=\"resources/test-dir\">\n" +
+				"    <directory name=\"empty\" mode=\"0755\" prot=\"drwxr-xr-x\">\n" +
+				"    </directory>\n    <directory name=\"hello\" mode=\"0755\" prot=\"drwxr-xr-x\">\n" +
+				"      <directory name=\"temp\" mode=\"0755\" prot=\"drwxr-xr-x\">\n      </directory>\n" +
+				"      <directory name=\"xelo\" mode=\"0755\" prot=\"drwxr-xr-x\">\n      </directory>\n" +
+				"    </directory>\n  </directory>\n  <report>\n    <directories>4</directories>\n  </report>\n</tree>"},
+		{cmd: "tree -J resources/test-dir/empty", desc: "JSON format empty dir test",
+			want: "[\n  {\"type\":\"directory\",\"name\":\"resources/test-dir/empty\",\"contents\":[\n" +
 				"  ]}\n,\n  {\"type\":\"report\",\"directories\":0,\"files\":0}\n]"},
-		{cmd: "tree -J ../resources/test-dir/hello/temp", desc: "JSON format single file in directory test",
-			want: "[\n  {\"type\":\"directory\",\"name\":\"../resources/test-dir/hello/temp\",\"contents\":[\n" +
+		{cmd: "tree -J resources/test-dir/hello/temp", desc: "JSON format single file in directory test",
+			want: "[\n  {\"type\":\"directory\",\"name\":\"resources/test-dir/hello/temp\",\"contents\":[\n" +
 				"    {\"type\":\"file\",\"name\":\"temp.txt\"}\n  ]}\n,\n  " +
 				"{\"type\":\"report\",\"directories\":0,\"files\":1}\n]"},
-		{cmd: "tree -J -p ../resources/test-dir/", desc: "Files in JSON format with permission mode test",
-			want: "[\n  {\"type\":\"directory\",\"name\":\"../resources/test-dir\",\"contents\":[\n" +
+		{cmd: "tree -J -p resources/test-dir/", desc: "Files in JSON format with permission mode test",
+			want: "[\n  {\"type\":\"directory\",\"name\":\"resources/test-dir\",\"contents\":[\n" +
 				"    {\"type\":\"directory\",\"name\":\"empty\",\"mode\":\"0755\",\"prot\":\"drwxr-xr-x\",\"contents\":[\n" +
-				"    ]}\n    {\"type\":\"directory\",\"name\":\"hello\",\"mode\":\"0755\",\"prot\":\"drwxr-xr-x\",\"contents\":[\n" +
-				"     {\"type\":\"file\",\"name\":\"hello.txt\",\"mode\":\"0644\",\"prot\":\"-rw-r--r--\"},\n" +
-				"     {\"type\":\"directory\",\"name\":\"temp\",\"mode\":\"0755\",\"prot\":\"drwxr-xr-x\",\"contents\":[\n" +
-				"      {\"type\":\"file\",\"name\":\"temp.txt\",\"mode\":\"0644\",\"prot\":\"-rw-r--r--\"}\n" +
-				"     ]}\n     {\"type\":\"directory\",\"name\":\"xelo\",\"mode\":\"0755\",\"prot\":\"drwxr-xr-x\",\"contents\":[\n" +
-				"      {\"type\":\"file\",\"name\":\"lwlo.rx\",\"mode\":\"0644\",\"prot\":\"-rw-r--r--\"}\n" +
-				"     ]}\n    ]},\n  ]}\n,\n  {\"type\":\"report\",\"directories\":4,\"files\":3}\n]"},
+				"    ]},\n    {\"type\":\"directory\",\"name\":\"hello\",\"mode\":\"0755\",\"prot\":\"drwxr-xr-x\",\"contents\":[\n" +
+				"      {\"type\":\"file\",\"name\":\"hello.txt\",\"mode\":\"0644\",\"prot\":\"-rw-r--r--\"},\n" +
+				"      {\"type\":\"directory\",\"name\":\"temp\",\"mode\":\"0755\",\"prot\":\"drwxr-xr-x\",\"contents\":[\n" +
+				"        {\"type\":\"file\",\"name\":\"temp.txt\",\"mode\":\"0644\",\"prot\":\"-rw-r--r--\"}\n" +
+				"      ]},\n      {\"type\":\"directory\",\"name\":\"xelo\",\"mode\":\"0755\",\"prot\":\"drwxr-xr-x\",\"contents\":[\n" +
+				"        {\"type\":\"file\",\"name\":\"lwlo.rx\",\"mode\":\"0644\",\"prot\":\"-rw-r--r--\"}\n" +
+				"      ]}\n    ]}\n  ]}\n,\n  {\"type\":\"report\",\"directories\":4,\"files\":3}\n]"},
 	}
 
 	assert := assert.New(t)
 	for _, t := range tests {
-		got := ListDirAndFiles(ParseCommand(t.cmd))
+		config, err := ParseCommand(t.cmd)
+		assert.NoError(err, t.desc)
+		config.FS = t.fsys
+		if config.FS == nil {
+			config.FS = testFS()
+		}
+		got := ListDirAndFiles(config)
 		assert.Equal(t.want, got, t.desc)
 	}
 }
+
+func TestListDirAndFilesOS(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "hello", "temp"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "hello", "hello.txt"), nil, 0644))
+
+	got := ListDirAndFiles(mustParse(t, "tree "+root))
+	want := root + "\n" +
+		"└── hello\n" +
+		"    │── hello.txt\n" +
+		"    └── temp\n\n" +
+		"2 directories, 1 file"
+	assert.Equal(t, want, got)
+}
+
+func TestReport(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a":     dir(0),
+		"a/d":   dir(1),
+		"a/d/x": file(2),
+		"a/y":   file(3),
+		"b":     dir(4),
+		"b/z":   file(5),
+	}
+
+	tests := []test{
+		{cmd: "tree a b", desc: "counts of each root and their total",
+			want: "a\n│── d\n│   └── x\n└── y\nb\n└── z\n\n" +
+				"a: 1 directory, 2 files\nb: 0 directories, 1 file\n1 directory, 3 files"},
+		{cmd: "tree -d a b", desc: "counts of each root with only directories",
+			want: "a\n└── d\nb\n\na: 1 directory\nb: 0 directories\n1 directory"},
+		{cmd: "tree --noreport a b", desc: "no report",
+			want: "a\n│── d\n│   └── x\n└── y\nb\n└── z"},
+		{cmd: "tree -J a b", desc: "roots are elements of one JSON array",
+			want: "[\n  {\"type\":\"directory\",\"name\":\"a\",\"contents\":[\n" +
+				"    {\"type\":\"directory\",\"name\":\"d\",\"contents\":[\n" +
+				"      {\"type\":\"file\",\"name\":\"x\"}\n    ]},\n" +
+				"    {\"type\":\"file\",\"name\":\"y\"}\n  ]},\n" +
+				"  {\"type\":\"directory\",\"name\":\"b\",\"contents\":[\n" +
+				"    {\"type\":\"file\",\"name\":\"z\"}\n  ]}\n,\n" +
+				"  {\"type\":\"report\",\"directories\":1,\"files\":3,\"roots\":[" +
+				"{\"name\":\"a\",\"directories\":1,\"files\":2},{\"name\":\"b\",\"directories\":0,\"files\":1}]}\n]"},
+		{cmd: "tree -J --noreport b", desc: "no JSON report",
+			want: "[\n  {\"type\":\"directory\",\"name\":\"b\",\"contents\":[\n" +
+				"    {\"type\":\"file\",\"name\":\"z\"}\n  ]}\n]"},
+		{cmd: "tree -X -L 1 a b", desc: "roots are elements of one XML document",
+			want: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<tree>\n" +
+				"  <directory name=\"a\">\n    <directory name=\"d\">\n    </directory>\n    <file name=\"y\"></file>\n  </directory>\n" +
+				"  <directory name=\"b\">\n    <file name=\"z\"></file>\n  </directory>\n" +
+				"  <report>\n    <directories>1</directories>\n    <files>2</files>\n" +
+				"    <root name=\"a\" directories=\"1\" files=\"1\"></root>\n" +
+				"    <root name=\"b\" directories=\"0\" files=\"1\"></root>\n  </report>\n</tree>"},
+		{cmd: "tree -X --noreport b", desc: "no XML report",
+			want: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<tree>\n" +
+				"  <directory name=\"b\">\n    <file name=\"z\"></file>\n  </directory>\n</tree>"},
+	}
+
+	for _, tc := range tests {
+		config := mustParse(t, tc.cmd)
+		config.FS = fsys
+		assert.Equal(t, tc.want, ListDirAndFiles(config), tc.desc)
+	}
+}
@@ -0,0 +1,69 @@
+package tree
+
+// Usage is the help text printed for --help.
+const Usage = `usage: tree [options] [--] [directory ...]
+
+Short options can be combined, as in -dfp or -L2. Long options take their
+argument as --opt=value or --opt value.
+
+Listing options:
+  -a                All files are listed, including hidden ones.
+  --hidden=WHEN     List hidden files: none, all or config (noise such as
+                    .git and .DS_Store stays hidden).
+  -d                List directories only.
+  -l                Follow symbolic links to directories.
+  -x                Stay on the file system of each directory given.
+  -f                Print the full path prefix of each file.
+  -L, --level level Descend only level directories deep.
+  -P pattern        List only files matching the pattern.
+  -I pattern        Do not list files matching the pattern.
+  --ignore-case     Ignore case when matching patterns.
+  --matchdirs       Apply -P to directory names as well.
+  --prune           Leave out empty directories.
+  --filelimit N     Do not open directories holding more than N entries.
+  --gitignore       Leave out files ignored by .gitignore.
+  --archives        List the contents of zip and tar archives.
+  --jobs N          Read directories with N workers.
+  --max-entries N   Stop listing after N entries.
+  --timeout d       Stop listing after the duration d, such as 10s or 1m.
+  --stats           Report the file system calls made and the time taken.
+
+File options:
+  -p                Print the protections of each file.
+  -u                Print the owner of each file.
+  -g                Print the group of each file.
+  --inodes          Print the inode number of each file.
+  --device          Print the device number of each file.
+  -s                Print the size of each file in bytes.
+  -h                Print sizes in a human readable way.
+  --si              Like -h, but use powers of 1000.
+  --du              Print directory sizes as the total of their contents,
+                    counting files with several hard links once.
+  --hardlinks       Mark files with several hard links and count them once,
+                    as -s and --du do.
+  -D                Print the modification time of each file.
+  --timefmt fmt     Print and format times with the strftime format fmt.
+
+Sorting options:
+  -v                Sort files by version.
+  -t                Sort files by modification time.
+  -c                Sort files by status change time, and show it with -D.
+  -U                Leave files unsorted.
+  -r                Reverse the order of the sort.
+  --sort-ignore-case
+                    Ignore case when sorting by name or version.
+  --dirsfirst       List directories before files.
+  --filesfirst      List files before directories.
+  --sort key        Sort by name, version, size, mtime, ctime or none.
+
+Output options:
+  -i                Do not print indentation lines.
+  -C                Always color names, using LS_COLORS.
+  -n                Never color names.
+  --noreport        Leave out the directory and file counts at the end.
+  -J                Print the tree as JSON.
+  -X                Print the tree as XML.
+  -H baseHREF       Print the tree as an HTML page linking to baseHREF.
+  --title title     Use title for the HTML page.
+  --help            Print this help.
+`
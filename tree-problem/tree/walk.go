@@ -0,0 +1,471 @@
+package tree
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	pathpkg "path"
+	"strings"
+	"sync"
+)
+
+// Report holds the directory and file counts of a walk and the total size
+// of the listed entries.
+type Report struct {
+	Directories, Files int
+	Size               int64
+	Roots              []RootReport // set when several roots are listed
+	Truncated          string       // why the walk stopped early, if it did
+	Stats              *Stats       // set with --stats
+}
+
+// RootReport holds the counts of one root of a listing with several roots;
+// the Report holds their totals.
+type RootReport struct {
+	Path               string
+	Directories, Files int
+	Size               int64
+}
+
+func (rr RootReport) report() Report {
+	return Report{Directories: rr.Directories, Files: rr.Files, Size: rr.Size}
+}
+
+// walker reads each directory once and hands its entries to a Renderer, so
+// level limiting, filtering and counting are shared by every format.
+type walker struct {
+	ctx    context.Context
+	config *TreeConfig
+	fsys   fs.FS
+	r      Renderer
+	report Report
+	keep   bool  // keep children in memory after rendering them
+	pool   *pool // reads directories ahead with --jobs
+	calls  calls
+
+	listed    int    // entries rendered so far, for --max-entries
+	truncated string // why the walk stopped early, if it did
+
+	links  map[fileKey]string // first path of each hard-linked file
+	owners map[fileKey]*Node  // where each hard-linked file counts in --du sizes
+
+	mu   sync.Mutex
+	errs []error // what could not be read, in walk order
+}
+
+func newWalker(config *TreeConfig, r Renderer) *walker {
+	return &walker{ctx: context.Background(), config: config, fsys: config.fileSystem(), r: r}
+}
+
+func (wk *walker) newRoot(path string) *Node {
+	root := path
+	if len(root) > 1 {
+		root = strings.TrimSuffix(root, PathSeperator)
+	}
+	n := &Node{Name: root, Path: root, Type: Directory, fsys: wk.fsys, fsPath: root}
+	if !isOS(wk.fsys) {
+		n.fsPath = pathpkg.Clean(root)
+	}
+
+	wk.calls.stat.Add(1)
+	fi, err := fs.Stat(wk.fsys, n.fsPath)
+	if err != nil {
+		n.Err = err
+		return n
+	}
+	n.setInfo(fi)
+	if n.Type == File && wk.config.archives && isArchive(n.Name) {
+		n.Type = Archive
+		return n
+	}
+	n.Type = Directory // roots are always listed as directories
+	if wk.config.gitignore {
+		n.ignore = aboveRoot(n.fsys, n.fsPath, &wk.calls)
+	}
+	if wk.config.followLinks {
+		n.key = newFileKey(n.fsys, n.fsPath, fi)
+	}
+	return n
+}
+
+// newNode builds the node for entry e of parent. The entry is only stat'ed
+// when the node has to carry its metadata or is a symbolic link.
+func (wk *walker) newNode(parent *Node, e *entry) *Node {
+	n := &Node{Name: e.Name(), Path: childPath(parent.Path, e.Name()), Type: nodeType(e.Type()), Mode: e.Type()}
+	n.fsys, n.fsPath = e.fsys, e.path
+
+	n.Archive = parent.Archive
+	if parent.Type == Archive {
+		n.Archive = parent.Path
+	}
+	n.matched = parent.matched
+	n.ignore = parent.ignore
+	n.parent = parent
+
+	if wk.needInfo() || (n.Type == Directory && (wk.config.followLinks || wk.config.oneFS)) {
+		fi, err := e.Info()
+		if err != nil {
+			n.Err = err
+			return n
+		}
+		n.setInfo(fi)
+		if n.Type == Directory && wk.config.followLinks {
+			n.key = newFileKey(n.fsys, n.fsPath, fi)
+		}
+	}
+	if n.Type == Link {
+		wk.resolveLink(n, e)
+	}
+	if wk.isArchiveEntry(parent, e) {
+		n.Type = Archive
+	}
+	if wk.config.oneFS && n.HasContents() && n.Type != Archive {
+		dev, ok := n.device()
+		parentDev, parentOK := parent.device()
+		n.otherFS = ok && parentOK && dev != parentDev
+	}
+	if wk.config.matchDirs && n.HasContents() && wk.config.matchPattern != "" {
+		n.matched = n.matched || matchPattern(wk.config.matchPattern, n.Name, wk.config.ignoreCase)
+	}
+	return n
+}
+
+// isArchiveEntry reports whether e is an archive the walker descends into;
+// archives nested inside archives are listed as plain files.
+func (wk *walker) isArchiveEntry(parent *Node, e fs.DirEntry) bool {
+	return wk.config.archives && parent.Type != Archive && parent.Archive == "" &&
+		e.Type().IsRegular() && isArchive(e.Name())
+}
+
+func (wk *walker) visit(n *Node, pos Position) error {
+	if pos.Depth > 0 && wk.stop() {
+		return nil
+	}
+	if (pos.Depth == 0 || n.HasContents()) && n.Err == nil {
+		// read the directory first, so its entry can tell it failed
+		wk.load(n, pos.Depth)
+		if wk.stop() && pos.Depth > 0 {
+			return nil // the read was cut short, so its contents are unknown
+		}
+	}
+	if n.Err != nil {
+		wk.fail(n.Err)
+	}
+
+	if pos.Depth > 0 {
+		wk.listed++
+		if n.hardLinked(wk.config) {
+			wk.markHardLink(n)
+		}
+		switch {
+		case n.HardLink != "":
+			// counted where it was first listed
+		case n.IsDir() || n.linkDir:
+			wk.report.Directories++
+		default:
+			wk.report.Files++
+		}
+		if !wk.config.du && n.HardLink == "" {
+			wk.report.Size += n.Size
+		}
+		if !n.HasContents() {
+			return wk.r.Entry(n, pos)
+		}
+	}
+
+	if err := wk.r.Entry(n, pos); err != nil {
+		return err
+	}
+
+	if pos.Depth == 0 && wk.config.du {
+		// like du -c, the total covers the root and everything below it
+		wk.sumSizes(n)
+		wk.report.Size = n.Size
+	}
+	for i, c := range n.Children {
+		if err := wk.visit(c, pos.child(i == len(n.Children)-1)); err != nil {
+			return err
+		}
+	}
+
+	if !wk.keep {
+		n.Children = nil
+	}
+	return wk.r.EndDir(n, pos)
+}
+
+// markHardLink points n at the path its file was first listed under, if it
+// was listed before, and otherwise remembers it under that path.
+func (wk *walker) markHardLink(n *Node) {
+	key := fileKey{dev: n.Device, ino: n.Inode}
+	if first, ok := wk.links[key]; ok {
+		n.HardLink = first
+		return
+	}
+	if wk.links == nil {
+		wk.links = make(map[fileKey]string)
+	}
+	wk.links[key] = n.Path
+}
+
+// stop reports whether the walk has to end before the next entry, because
+// its context is done or --max-entries entries were listed, and records why.
+func (wk *walker) stop() bool {
+	if wk.truncated != "" {
+		return true
+	}
+	switch err := wk.ctx.Err(); {
+	case errors.Is(err, context.DeadlineExceeded):
+		wk.truncated = "timed out"
+	case err != nil:
+		wk.truncated = "canceled"
+	case wk.config.maxEntries > 0 && wk.listed >= wk.config.maxEntries:
+		wk.truncated = fmt.Sprintf("reached the limit of %d entries", wk.config.maxEntries)
+	}
+	return wk.truncated != ""
+}
+
+// fail records err for the WalkError returned once the tree is rendered.
+func (wk *walker) fail(err error) {
+	wk.mu.Lock()
+	wk.errs = append(wk.errs, err)
+	wk.mu.Unlock()
+}
+
+// load reads the children of n, found at depth, unless they were already
+// read or lie beyond the level limit and --du does not need them.
+func (wk *walker) load(n *Node, depth int) {
+	if !wk.reads(depth) {
+		return
+	}
+	if wk.pool != nil {
+		wk.pool.load(n, depth)
+		return
+	}
+	if !n.loaded {
+		wk.read(n, depth)
+	}
+}
+
+func (wk *walker) read(n *Node, depth int) {
+	n.Children = wk.readChildren(n, depth)
+	n.loaded = true
+}
+
+// beyondLevel reports whether directories at depth lie beyond the -L limit.
+func (wk *walker) beyondLevel(depth int) bool {
+	return wk.config.level > 0 && depth >= wk.config.level
+}
+
+// reads reports whether directories at depth are read: within the -L limit,
+// or at any depth for the sizes of --du.
+func (wk *walker) reads(depth int) bool {
+	return wk.config.du || !wk.beyondLevel(depth)
+}
+
+// startJobs starts the --jobs workers, if any, and returns the function
+// that stops them.
+func (wk *walker) startJobs() func() {
+	if wk.config.jobs < 2 {
+		return func() {}
+	}
+	wk.pool = newPool(wk, wk.config.jobs)
+	return wk.pool.close
+}
+
+func (wk *walker) readChildren(dir *Node, depth int) []*Node {
+	if dir.otherFS {
+		return nil
+	}
+	fsys, dirPath := dir.fsys, dir.fsPath
+	if dir.Type == Archive {
+		wk.calls.open.Add(1)
+		afs, err := openArchive(dir.fsys, dir.fsPath)
+		if err != nil {
+			dir.Err = err
+			return nil
+		}
+		fsys, dirPath = afs, "."
+		dir.ignore = nil // ignore rules do not reach into archives
+	}
+
+	if wk.config.gitignore {
+		dir.ignore = dir.ignore.load(fsys, dirPath, &wk.calls)
+	}
+
+	wk.calls.readDir.Add(1)
+	entries, err := wk.readDir(fsys, dirPath)
+	if err != nil {
+		if wk.ctx.Err() != nil {
+			return nil // the walk is stopping
+		}
+		dir.Err = err
+	}
+
+	files := wk.filterEntries(dir, wk.entries(fsys, dirPath, entries))
+	if wk.config.fileLimit > 0 && len(files) > wk.config.fileLimit {
+		dir.OverLimit = len(files)
+		return nil
+	}
+	nodes := make([]*Node, 0, len(files))
+	for _, f := range files {
+		nodes = append(nodes, wk.newNode(dir, f.(*entry)))
+	}
+	if wk.config.prune || wk.config.du {
+		nodes = wk.readSubtrees(nodes, depth+1)
+	}
+
+	sortNodes(nodes, *wk.config)
+	return nodes
+}
+
+// readSubtrees reads the directories among nodes, found at depth, as --prune
+// and --du need their subtrees to know whether they end up empty or how much
+// they hold. It returns the nodes --prune keeps.
+func (wk *walker) readSubtrees(nodes []*Node, depth int) []*Node {
+	if wk.pool != nil {
+		// all of them go to the workers before any is waited on
+		wk.pool.enqueue(nodes, depth)
+	}
+	kept := nodes[:0]
+	for _, n := range nodes {
+		if n.HasContents() {
+			wk.load(n, depth)
+			if wk.config.du && wk.beyondLevel(depth) {
+				wk.collapse(n)
+			}
+			if wk.config.prune && !wk.beyondLevel(depth) && n.loaded && len(n.Children) == 0 && !n.unopened() {
+				continue
+			}
+		}
+		kept = append(kept, n)
+	}
+	return kept
+}
+
+// readDir reads the directory name of fsys, giving up as soon as the context
+// of the walk is done: a read stuck on a hung mount is abandoned to its
+// goroutine rather than waited for.
+func (wk *walker) readDir(fsys fs.FS, name string) ([]fs.DirEntry, error) {
+	unsorted := wk.config.sortKey == SortNone
+	if wk.ctx.Done() == nil {
+		return readDir(fsys, name, unsorted)
+	}
+
+	type result struct {
+		entries []fs.DirEntry
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		entries, err := readDir(fsys, name, unsorted)
+		done <- result{entries, err}
+	}()
+	select {
+	case res := <-done:
+		return res.entries, res.err
+	case <-wk.ctx.Done():
+		return nil, wk.ctx.Err()
+	}
+}
+
+// linkSize is a hard-linked file below a directory whose contents are not
+// listed, kept until the listing tells where the file counts.
+type linkSize struct {
+	key  fileKey
+	size int64
+}
+
+// collapse adds the sizes in the subtree of n, whose contents are not listed,
+// to the size of n as soon as it is read and drops the subtree, keeping only
+// its hard-linked files for sumSizes.
+func (wk *walker) collapse(n *Node) {
+	for _, c := range n.Children {
+		if c.Err != nil {
+			wk.fail(c.Err) // never visited, so recorded here
+		}
+		if c.hardLinked(wk.config) {
+			n.links = append(n.links, linkSize{fileKey{dev: c.Device, ino: c.Inode}, c.Size})
+			continue
+		}
+		n.Size += c.Size
+		n.links = append(n.links, c.links...)
+	}
+	n.Children = nil
+}
+
+// sumSizes sets the --du sizes of root and the directories below it, once
+// they are all read. A file with several hard links counts in the directory
+// it is listed in first, in the order of the listing; one that is not listed
+// counts where the walk meets it first.
+func (wk *walker) sumSizes(root *Node) {
+	if wk.owners == nil {
+		wk.owners = make(map[fileKey]*Node)
+	}
+	wk.claimLinks(root)
+	wk.addSizes(root)
+}
+
+// claimLinks makes the listed hard-linked files below n count where they are
+// listed first.
+func (wk *walker) claimLinks(n *Node) {
+	for _, c := range n.Children {
+		if c.hardLinked(wk.config) {
+			key := fileKey{dev: c.Device, ino: c.Inode}
+			if wk.owners[key] == nil {
+				wk.owners[key] = c
+			}
+		}
+		wk.claimLinks(c)
+	}
+}
+
+func (wk *walker) addSizes(n *Node) {
+	for _, c := range n.Children {
+		wk.addSizes(c)
+		if c.hardLinked(wk.config) && wk.owners[fileKey{dev: c.Device, ino: c.Inode}] != c {
+			continue // like du, each file is counted once
+		}
+		n.Size += c.Size
+	}
+	for _, l := range n.links {
+		if wk.owners[l.key] == nil {
+			wk.owners[l.key] = n
+			n.Size += l.size
+		}
+	}
+	n.links = nil
+}
+
+func GetFiles(root string, config TreeConfig) ([]fs.DirEntry, error) {
+	wk := newWalker(&config, discardRenderer{})
+	files, err := fs.ReadDir(wk.fsys, root)
+	dir := wk.newRoot(root)
+	return wk.filterEntries(dir, wk.entries(wk.fsys, dir.fsPath, files)), err
+}
+
+func ReadDir(root string) ([]fs.DirEntry, error) {
+	return os.ReadDir(root)
+}
+
+func IgnoreDotFiles(files []fs.DirEntry) []fs.DirEntry {
+	fs := make([]fs.DirEntry, 0)
+	for _, f := range files {
+		if !strings.HasPrefix(f.Name(), ".") {
+			fs = append(fs, f)
+		}
+	}
+	return fs
+}
+
+func ReadOnlyDir(files []fs.DirEntry) []fs.DirEntry {
+	dirs := make([]fs.DirEntry, 0)
+	for _, f := range files {
+		if f.IsDir() {
+			dirs = append(dirs, f)
+		}
+	}
+	return dirs
+}
@@ -0,0 +1,264 @@
+package tree
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxEntries(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a":       dir(0),
+		"a/b":     dir(1),
+		"a/b/c":   file(2),
+		"a/b/d":   file(3),
+		"a/e":     file(4),
+		"other":   dir(5),
+		"other/f": file(6),
+	}
+
+	tests := []test{
+		{cmd: "tree --max-entries 5 a", desc: "a limit that is not reached",
+			want: "a\n│── b\n│   │── c\n│   └── d\n└── e\n\n1 directory, 3 files"},
+		{cmd: "tree --max-entries 2 a other", desc: "the walk stops after the limit",
+			want: "a\n│── b\n│   │── c\n\n" +
+				"a: 1 directory, 1 file\n1 directory, 1 file\n" +
+				"listing truncated: reached the limit of 2 entries"},
+		{cmd: "tree --max-entries 2 --noreport a", desc: "truncation is told without the report",
+			want: "a\n│── b\n│   │── c\n\nlisting truncated: reached the limit of 2 entries"},
+		{cmd: "tree -J --max-entries 2 a other", desc: "a truncated JSON tree is complete",
+			want: "[\n  {\"type\":\"directory\",\"name\":\"a\",\"contents\":[\n" +
+				"    {\"type\":\"directory\",\"name\":\"b\",\"contents\":[\n" +
+				"      {\"type\":\"file\",\"name\":\"c\"}\n    ]}\n  ]}\n,\n" +
+				"  {\"type\":\"report\",\"directories\":1,\"files\":1," +
+				"\"roots\":[{\"name\":\"a\",\"directories\":1,\"files\":1}]," +
+				"\"truncated\":\"reached the limit of 2 entries\"}\n]"},
+		{cmd: "tree -X --max-entries 1 --noreport a", desc: "a truncated XML tree is complete",
+			want: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<tree>\n" +
+				"  <directory name=\"a\">\n    <directory name=\"b\">\n    </directory>\n  </directory>\n" +
+				"  <report>\n    <truncated>reached the limit of 1 entries</truncated>\n  </report>\n</tree>"},
+	}
+
+	for _, tc := range tests {
+		config := mustParse(t, tc.cmd)
+		config.FS = fsys
+		var sb strings.Builder
+		assert.NoError(t, WriteTree(&sb, config), tc.desc)
+		assert.Equal(t, tc.want, strings.TrimSuffix(sb.String(), NewLine), tc.desc)
+	}
+}
+
+// hungFS blocks reading the directory hung until release is closed, as a
+// stale network mount would, and refuses to read denied.
+type hungFS struct {
+	fstest.MapFS
+	hung    string
+	denied  string
+	release chan struct{}
+}
+
+func (h hungFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name == h.hung {
+		<-h.release
+	}
+	if name == h.denied {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return h.MapFS.ReadDir(name)
+}
+
+func TestTimeout(t *testing.T) {
+	fsys := hungFS{
+		MapFS: fstest.MapFS{
+			"a":        dir(0),
+			"a/b":      file(1),
+			"a/nfs":    dir(2),
+			"a/nfs/x":  file(3),
+			"a/z":      file(4),
+			"a/zz":     dir(5),
+			"a/zz/zzz": file(6),
+		},
+		hung:    "a/nfs",
+		release: make(chan struct{}),
+	}
+	defer close(fsys.release)
+
+	for _, cmd := range []string{"tree -J --timeout 20ms a", "tree -J --timeout 20ms --jobs 4 a"} {
+		config := mustParse(t, cmd)
+		config.FS = fsys
+		var sb strings.Builder
+		err := WriteTree(&sb, config)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded), "%v: %v", cmd, err)
+
+		out := sb.String()
+		assert.True(t, json.Valid([]byte(out)), "%v:\n%v", cmd, out)
+		assert.Contains(t, out, "\"name\":\"b\"", cmd)
+		assert.NotContains(t, out, "\"name\":\"nfs\"", cmd)
+		assert.Contains(t, out, "\"truncated\":\"timed out\"", cmd)
+	}
+}
+
+func TestTimeoutWithWalkErrors(t *testing.T) {
+	fsys := hungFS{
+		MapFS:   fstest.MapFS{"a": dir(0), "a/bad": dir(1), "a/nfs": dir(2), "a/nfs/x": file(3)},
+		hung:    "a/nfs",
+		denied:  "a/bad",
+		release: make(chan struct{}),
+	}
+	defer close(fsys.release)
+
+	config := mustParse(t, "tree --timeout 20ms a")
+	config.FS = fsys
+	err := WriteTree(io.Discard, config)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), err)
+	var walkErr *WalkError
+	if assert.True(t, errors.As(err, &walkErr), err) {
+		assert.Len(t, walkErr.Errs, 1)
+		assert.True(t, errors.Is(walkErr.Errs[0], fs.ErrPermission), walkErr.Errs[0])
+	}
+}
+
+func TestRenderTreeContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	config := mustParse(t, "tree a")
+	config.FS = fstest.MapFS{"a": dir(0), "a/b": file(1)}
+	var sb strings.Builder
+	err := WriteTreeContext(ctx, &sb, config)
+	assert.True(t, errors.Is(err, context.Canceled), err)
+	assert.Equal(t, "a\n\n0 directories, 0 files\nlisting truncated: canceled\n", sb.String())
+}
+
+func TestFileLimit(t *testing.T) {
+	fsys := fstest.MapFS{
+		"cache":           dir(0),
+		"cache/shards":    dir(1),
+		"cache/shards/1":  file(2),
+		"cache/shards/2":  file(3),
+		"cache/shards/3":  file(4),
+		"cache/shards/.4": file(5),
+		"cache/small":     dir(6),
+		"cache/small/x":   file(7),
+	}
+
+	tests := []test{
+		{cmd: "tree --filelimit 2 cache", desc: "large directories are not opened",
+			want: "cache\n" +
+				"│── shards [3 entries exceeds filelimit, not opening dir]\n" +
+				"└── small\n" +
+				"    └── x\n\n" +
+				"2 directories, 1 file"},
+		{cmd: "tree --filelimit 3 cache", desc: "the limit itself is allowed",
+			want: "cache\n│── shards\n│   │── 1\n│   │── 2\n│   └── 3\n└── small\n    └── x\n\n2 directories, 4 files"},
+		{cmd: "tree -a --filelimit 3 --prune cache", desc: "listed entries count and unopened directories are not pruned",
+			want: "cache\n" +
+				"│── shards [4 entries exceeds filelimit, not opening dir]\n" +
+				"└── small\n" +
+				"    └── x\n\n" +
+				"2 directories, 1 file"},
+		{cmd: "tree --filelimit 1 cache", desc: "the root is limited too",
+			want: "cache [2 entries exceeds filelimit, not opening dir]\n\n0 directories, 0 files"},
+		{cmd: "tree -J --filelimit 2 cache", desc: "JSON error field",
+			want: "[\n  {\"type\":\"directory\",\"name\":\"cache\",\"contents\":[\n" +
+				"    {\"type\":\"directory\",\"name\":\"shards\",\"error\":\"3 entries exceeds filelimit, not opening dir\",\"contents\":[\n    ]},\n" +
+				"    {\"type\":\"directory\",\"name\":\"small\",\"contents\":[\n" +
+				"      {\"type\":\"file\",\"name\":\"x\"}\n    ]}\n  ]}\n,\n" +
+				"  {\"type\":\"report\",\"directories\":2,\"files\":1}\n]"},
+		{cmd: "tree -X --filelimit 2 cache/shards", desc: "XML error attribute",
+			want: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<tree>\n" +
+				"  <directory name=\"cache/shards\" error=\"3 entries exceeds filelimit, not opening dir\">\n  </directory>\n" +
+				"  <report>\n    <directories>0</directories>\n    <files>0</files>\n  </report>\n</tree>"},
+	}
+
+	for _, tc := range tests {
+		config := mustParse(t, tc.cmd)
+		config.FS = fsys
+		var sb strings.Builder
+		assert.NoError(t, WriteTree(&sb, config), tc.desc)
+		assert.Equal(t, tc.want, strings.TrimSuffix(sb.String(), NewLine), tc.desc)
+	}
+}
+
+// recordWriter notes how many directories had been read at each write, and
+// fails from write number failAt on when it is set.
+type recordWriter struct {
+	fsys   countFS
+	reads  []int32
+	failAt int
+}
+
+var errWrite = errors.New("disk full")
+
+func (w *recordWriter) Write(p []byte) (int, error) {
+	if w.failAt > 0 && len(w.reads) >= w.failAt-1 {
+		return 0, errWrite
+	}
+	w.reads = append(w.reads, atomic.LoadInt32(w.fsys.reads))
+	return len(p), nil
+}
+
+func TestWriteTreeStreams(t *testing.T) {
+	const dirs = 20
+	for _, cmd := range []string{"tree root", "tree -J root", "tree -X root"} {
+		w := &recordWriter{fsys: newCountFS(manyDirs(dirs), 0)}
+		config := mustParse(t, cmd)
+		config.FS = w.fsys
+		assert.NoError(t, WriteTree(w, config), cmd)
+		if assert.NotEmpty(t, w.reads, cmd) {
+			assert.Less(t, w.reads[0], int32(1+dirs), "%v: the first write waits for the whole walk", cmd)
+		}
+		assert.Equal(t, int32(1+dirs), atomic.LoadInt32(w.fsys.reads), cmd)
+	}
+}
+
+func TestWriteTreeWriterFails(t *testing.T) {
+	const dirs = 20
+	for _, cmd := range []string{"tree root", "tree -J root", "tree -X root"} {
+		w := &recordWriter{fsys: newCountFS(manyDirs(dirs), 0), failAt: 3}
+		config := mustParse(t, cmd)
+		config.FS = w.fsys
+		err := WriteTree(w, config)
+		assert.True(t, errors.Is(err, errWrite), "%v: %v", cmd, err)
+		assert.Less(t, atomic.LoadInt32(w.fsys.reads), int32(1+dirs), "%v: the walk goes on after the write failed", cmd)
+	}
+}
+
+// pathRenderer collects the paths of the nodes below the roots.
+type pathRenderer struct {
+	discardRenderer
+	paths []string
+}
+
+func (p *pathRenderer) Entry(n *Node, pos Position) error {
+	if pos.Depth > 0 {
+		p.paths = append(p.paths, n.Path)
+	}
+	return nil
+}
+
+func TestSlashRoot(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no / root")
+	}
+	r := &pathRenderer{}
+	assert.NoError(t, RenderTree(r, mustParse(t, "tree -L 1 -a /")))
+	if assert.NotEmpty(t, r.paths) {
+		for _, p := range r.paths {
+			assert.Regexp(t, "^/[^/]+$", p)
+		}
+	}
+
+	var sb strings.Builder
+	assert.NoError(t, WriteTree(&sb, mustParse(t, "tree -f -L 1 --noreport /")))
+	assert.NotContains(t, sb.String(), "//")
+}
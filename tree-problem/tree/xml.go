@@ -0,0 +1,182 @@
+package tree
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// xmlRenderer writes the GNU tree 2.x XML schema: a <tree> element holding
+// one element per root, named after the type of each entry, and a <report>.
+// Markup is produced by encoding/xml, which escapes names and keeps the
+// document well formed; only the indentation is written by hand.
+type xmlRenderer struct {
+	enc    *xml.Encoder
+	config TreeConfig
+}
+
+func NewXMLRenderer(w io.Writer, config TreeConfig) Renderer {
+	return &xmlRenderer{enc: xml.NewEncoder(w), config: config}
+}
+
+func (x *xmlRenderer) Begin() error {
+	return x.write(
+		xml.ProcInst{Target: "xml", Inst: []byte(`version="1.0" encoding="UTF-8"`)},
+		xml.CharData(NewLine),
+		xml.StartElement{Name: xml.Name{Local: Command}},
+		xml.CharData(NewLine),
+	)
+}
+
+func (x *xmlRenderer) Entry(n *Node, pos Position) error {
+	start := xml.StartElement{Name: xml.Name{Local: xmlTag(n, pos)}, Attr: x.attrs(n, pos)}
+	tokens := []xml.Token{xml.CharData(xmlIndent(pos.Depth)), start}
+	if pos.Depth > 0 && !n.HasContents() {
+		tokens = append(tokens, start.End())
+	}
+	return x.write(append(tokens, xml.CharData(NewLine))...)
+}
+
+func (x *xmlRenderer) EndDir(n *Node, pos Position) error {
+	end := xml.EndElement{Name: xml.Name{Local: xmlTag(n, pos)}}
+	return x.write(xml.CharData(xmlIndent(pos.Depth)), end, xml.CharData(NewLine))
+}
+
+func (x *xmlRenderer) End(r Report) error {
+	end := []xml.Token{xml.EndElement{Name: xml.Name{Local: Command}}, xml.CharData(NewLine)}
+	if x.config.noReport && r.Stats == nil && r.Truncated == "" {
+		return x.write(end...)
+	}
+
+	report := xml.StartElement{Name: xml.Name{Local: "report"}}
+	tokens := []xml.Token{xml.CharData(xmlIndent(0)), report, xml.CharData(NewLine)}
+	if !x.config.noReport {
+		if x.config.showSize() {
+			tokens = append(tokens, xmlElement("size", strconv.FormatInt(r.Size, 10))...)
+		}
+		tokens = append(tokens, xmlElement("directories", strconv.Itoa(r.Directories))...)
+		if !x.config.reqOnlyDir {
+			tokens = append(tokens, xmlElement("files", strconv.Itoa(r.Files))...)
+		}
+		for _, rr := range r.Roots {
+			root := xml.StartElement{Name: xml.Name{Local: "root"}, Attr: []xml.Attr{xmlAttr("name", rr.Path)}}
+			if x.config.showSize() {
+				root.Attr = append(root.Attr, xmlAttr("size", strconv.FormatInt(rr.Size, 10)))
+			}
+			root.Attr = append(root.Attr, xmlAttr("directories", strconv.Itoa(rr.Directories)))
+			if !x.config.reqOnlyDir {
+				root.Attr = append(root.Attr, xmlAttr("files", strconv.Itoa(rr.Files)))
+			}
+			tokens = append(tokens, xml.CharData(xmlIndent(1)), root, root.End(), xml.CharData(NewLine))
+		}
+	}
+	if r.Truncated != "" {
+		tokens = append(tokens, xmlElement("truncated", r.Truncated)...)
+	}
+	if s := r.Stats; s != nil {
+		stats := xml.StartElement{Name: xml.Name{Local: "stats"}, Attr: []xml.Attr{
+			xmlAttr("readdir", strconv.FormatInt(s.ReadDir, 10)),
+			xmlAttr("stat", strconv.FormatInt(s.Stat, 10)),
+			xmlAttr("lstat", strconv.FormatInt(s.Lstat, 10)),
+			xmlAttr("readlink", strconv.FormatInt(s.ReadLink, 10)),
+			xmlAttr("open", strconv.FormatInt(s.Open, 10)),
+			xmlAttr("elapsed", s.Elapsed.String()),
+		}}
+		tokens = append(tokens, xml.CharData(xmlIndent(1)), stats, stats.End(), xml.CharData(NewLine))
+	}
+	tokens = append(tokens, xml.CharData(xmlIndent(0)), report.End(), xml.CharData(NewLine))
+	return x.write(append(tokens, end...)...)
+}
+
+// write encodes tokens and flushes them, so the document is streamed as the
+// walk goes.
+func (x *xmlRenderer) write(tokens ...xml.Token) error {
+	for _, t := range tokens {
+		if err := x.enc.EncodeToken(t); err != nil {
+			return err
+		}
+	}
+	return x.enc.Flush()
+}
+
+// attrs returns the attributes of n, in the order of the GNU schema.
+func (x *xmlRenderer) attrs(n *Node, pos Position) []xml.Attr {
+	if pos.Depth == 0 {
+		attrs := []xml.Attr{xmlAttr("name", n.Path)}
+		if note := errorNote(n); note != "" {
+			attrs = append(attrs, xmlAttr("error", note))
+		}
+		return attrs
+	}
+
+	attrs := []xml.Attr{xmlAttr("name", n.Name)}
+	if n.Target != "" {
+		attrs = append(attrs, xmlAttr("target", n.Target))
+	}
+	if n.Archive != "" {
+		attrs = append(attrs, xmlAttr("archive", n.Archive))
+	}
+	if x.config.reqInode && n.ids {
+		attrs = append(attrs, xmlAttr("inode", strconv.FormatUint(n.Inode, 10)))
+	}
+	if x.config.reqDevice && n.ids {
+		attrs = append(attrs, xmlAttr("dev", strconv.FormatUint(n.Device, 10)))
+	}
+	if x.config.reqFilePermsn {
+		attrs = append(attrs, xmlAttr("mode", getPermsnMode(n, true)), xmlAttr("prot", getPermsnMode(n, false)))
+	}
+	if (x.config.reqUser || x.config.reqGroup) && n.ids {
+		userName, groupName := ownerNames(n)
+		if x.config.reqUser {
+			attrs = append(attrs, xmlAttr("user", userName))
+		}
+		if x.config.reqGroup {
+			attrs = append(attrs, xmlAttr("group", groupName))
+		}
+	}
+	if x.config.showSize() {
+		attrs = append(attrs, xmlAttr("size", strconv.FormatInt(n.Size, 10)))
+	}
+	if x.config.showTime() {
+		attrs = append(attrs, xmlAttr("time", formatTime(nodeTime(n, x.config), x.config)))
+	}
+	if n.hardLinked(&x.config) {
+		attrs = append(attrs, xmlAttr("links", strconv.FormatUint(n.Links, 10)))
+		if n.HardLink != "" {
+			attrs = append(attrs, xmlAttr("hardlink", n.HardLink))
+		}
+	}
+	if n.Broken {
+		attrs = append(attrs, xmlAttr("broken", "true"))
+	}
+	if n.Recursive {
+		attrs = append(attrs, xmlAttr("error", "recursive, not followed"))
+	} else if note := errorNote(n); note != "" {
+		attrs = append(attrs, xmlAttr("error", note))
+	}
+	return attrs
+}
+
+// xmlTag returns the element name of n; roots are always directories.
+func xmlTag(n *Node, pos Position) string {
+	if pos.Depth == 0 {
+		return "directory"
+	}
+	return n.Type.String()
+}
+
+func xmlAttr(name, value string) xml.Attr {
+	return xml.Attr{Name: xml.Name{Local: name}, Value: value}
+}
+
+// xmlElement returns the tokens of a report line holding value.
+func xmlElement(name, value string) []xml.Token {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	return []xml.Token{xml.CharData(xmlIndent(1)), start, xml.CharData(value), start.End(), xml.CharData(NewLine)}
+}
+
+// xmlIndent returns the indentation of the elements at depth.
+func xmlIndent(depth int) string {
+	return strings.Repeat(Space, 2*depth+2)
+}
@@ -0,0 +1,76 @@
+package tree
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type xmlEntry struct {
+	XMLName xml.Name
+	Name    string     `xml:"name,attr"`
+	Entries []xmlEntry `xml:",any"`
+	Value   string     `xml:",chardata"`
+}
+
+// names returns the tags and names of the entries below e, depth first.
+func (e xmlEntry) names() []string {
+	var names []string
+	for _, c := range e.Entries {
+		names = append(names, c.XMLName.Local+":"+c.Name)
+		names = append(names, c.names()...)
+	}
+	return names
+}
+
+func TestXMLParsesBack(t *testing.T) {
+	config := mustParse(t, "tree -X odd")
+	config.FS = awkwardFS()
+	var got xmlEntry
+	assert.NoError(t, xml.Unmarshal([]byte(ListDirAndFiles(config)), &got))
+
+	assert.Equal(t, "tree", got.XMLName.Local)
+	assert.Equal(t, []string{
+		"directory:odd",
+		"file:'quote'",
+		"directory:a&b <c>",
+		"file:tab\there",
+		"file:back\\slash",
+		"file:line\nbreak",
+		"file:say \"hi\"",
+		"file:ünï©ødé ✓",
+		"report:",
+		"directories:",
+		"files:",
+	}, got.names())
+
+	report := got.Entries[1]
+	assert.Equal(t, "1", strings.TrimSpace(report.Entries[0].Value))
+	assert.Equal(t, "6", strings.TrimSpace(report.Entries[1].Value))
+}
+
+func TestXMLIsWellFormed(t *testing.T) {
+	cmds := []string{
+		"tree -X odd",
+		"tree -X -d odd",
+		"tree -X -p -s -D -f odd",
+		"tree -X --du -h -L 1 odd",
+		"tree -X --stats -P *e* --prune odd",
+		"tree -X -s odd odd",
+		"tree -X --noreport odd odd",
+		"tree -X --noreport --stats odd",
+	}
+	for _, cmd := range cmds {
+		config := mustParse(t, cmd)
+		config.FS = awkwardFS()
+		out := ListDirAndFiles(config)
+		d := xml.NewDecoder(strings.NewReader(out))
+		var err error
+		for err == nil {
+			_, err = d.Token()
+		}
+		assert.Equal(t, "EOF", err.Error(), "%v:\n%v", cmd, out)
+	}
+}